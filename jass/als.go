@@ -0,0 +1,361 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Almost locked set (ALS): N unoccupied cells in a unit with N+1 candidates
+ * between them. If any one of the candidates is removed, the rest are locked
+ * in the cells.
+ */
+type ALS struct {
+	cells PointSet
+	cands CandidateSet
+}
+
+func (als ALS) ToString1() string {
+	return fmt.Sprintf("%v[%s]", als.cands, als.cells.ToString1())
+}
+
+/*
+ * Returns the cells of the ALS where nr is possible
+ */
+func (als ALS) cellsWith(game *Game, nr Num) PointSet {
+	cells := PointSet{}
+	for _, cell := range als.cells {
+		if game.poss.Get(Num(cell.y), Num(cell.x), nr) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+/*
+ * Finds all the almost locked sets in rows, cols and boxes. Sets in the
+ * intersection of a box and a line are only returned once.
+ */
+func findALSs(game *Game) []ALS {
+	alss := []ALS{}
+	known := map[string]bool{}
+
+	for unit := 0; unit < NrUnits; unit++ {
+		cells := []Point{}
+		for _, cell := range unitCells(unit) {
+			if !game.board.CellOccupied(cell) {
+				cells = append(cells, cell)
+			}
+		}
+		for size := 1; size < len(cells); size++ {
+			comb(len(cells), size, func(c []int) {
+				als := ALS{cells: PointSet{}, cands: CandidateSet{}}
+				for _, i := range c {
+					als.cells = append(als.cells, cells[i])
+					als.cands = als.cands.Add(game.poss.CellCandidates(cells[i]))
+				}
+				if len(als.cands) != size+1 {
+					return
+				}
+				key := als.cells.ToString1()
+				if !known[key] {
+					known[key] = true
+					alss = append(alss, als)
+				}
+			})
+		}
+	}
+
+	return alss
+}
+
+/*
+ * A restricted common candidate (RCC) of two non-overlapping ALSs is a
+ * candidate of both whose cells in one ALS all see its cells in the other.
+ * It can be in one of the ALSs at most, so if it is in one of them, the other
+ * one is locked without it.
+ */
+type alsLink struct {
+	to int
+	nr Num
+}
+
+/*
+ * The ALSs of the board with the cells of each candidate (indices
+ * 0...NR_MAX-1) and the RCCs between them
+ */
+type alsGraph struct {
+	alss  []ALS
+	cells [][]PointSet
+	links [][]alsLink
+}
+
+/*
+ * The candidates of each cell as bit masks (bit k-1 for k)
+ */
+type candidateMasks [Y][X]uint16
+
+func (game *Game) candidateMasks() candidateMasks {
+	var masks candidateMasks
+	for y := range masks {
+		for x := range masks[y] {
+			for nr := Num(1); nr <= NR_MAX; nr++ {
+				if game.poss.Get(Num(y), Num(x), nr) {
+					masks[y][x] |= 1 << (nr - 1)
+				}
+			}
+		}
+	}
+	return masks
+}
+
+/*
+ * Returns the ALS graph of the board, shared by the ALS techniques. It is
+ * only built again if the candidates have changed since, so at most once per
+ * pass of Solve.
+ */
+func (game *Game) sharedALSGraph() *alsGraph {
+	masks := game.candidateMasks()
+	if game.alsGraph == nil || masks != game.alsMasks {
+		game.alsGraph = newALSGraph(game)
+		game.alsMasks = masks
+	}
+	return game.alsGraph
+}
+
+func newALSGraph(game *Game) *alsGraph {
+	graph := &alsGraph{alss: findALSs(game)}
+	n := len(graph.alss)
+	graph.cells = make([][]PointSet, n)
+	graph.links = make([][]alsLink, n)
+	masks := make([]cellMask, n)
+
+	for i, als := range graph.alss {
+		graph.cells[i] = make([]PointSet, NR_MAX)
+		for _, nr := range als.cands {
+			graph.cells[i][nr-1] = als.cellsWith(game, nr)
+		}
+		masks[i] = maskOf(als.cells...)
+	}
+	for i, a := range graph.alss {
+		for j := i + 1; j < n; j++ {
+			if masks[i].intersects(masks[j]) {
+				continue
+			}
+			for _, nr := range a.cands.Intersect(graph.alss[j].cands) {
+				if graph.restricted(i, j, nr) {
+					graph.links[i] = append(graph.links[i], alsLink{j, nr})
+					graph.links[j] = append(graph.links[j], alsLink{i, nr})
+				}
+			}
+		}
+	}
+	return graph
+}
+
+/*
+ * Returns true if all the cells of nr in ALS a see all of them in ALS b
+ */
+func (graph *alsGraph) restricted(a, b int, nr Num) bool {
+	for _, cell := range graph.cells[a][nr-1] {
+		if !seesAll(cell, graph.cells[b][nr-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Eliminates nr from all the cells that see all its cells in the given ALSs
+ */
+func (graph *alsGraph) eliminate(game *Game, nr Num, alss ...int) int {
+	targets := PointSet{}
+	for _, i := range alss {
+		targets = append(targets, graph.cells[i][nr-1]...)
+	}
+	return eliminateFromPeers(game, nr, targets...)
+}
+
+/*
+ * Returns true if the two ALSs have no cells in common
+ */
+func (graph *alsGraph) disjoint(a, b int) bool {
+	for _, cell := range graph.alss[a].cells {
+		if graph.alss[b].cells.Contains(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * ALS-XZ: two ALSs A and B with an RCC x. One of them is locked without x,
+ * so any other common candidate z is in A or B, and it can be eliminated
+ * from all the cells seeing all the z cells of both.
+ *
+ * With two RCCs x and y, both ALSs are locked without one of them, so all
+ * the candidates can be eliminated from the cells seeing all their cells in
+ * either ALS, and x and y from the cells seeing their cells in both.
+ */
+func (scanner *Scanner) ScanALSXZ() int {
+	game := scanner.game
+	graph := game.sharedALSGraph()
+	found := 0
+
+	for a := range graph.alss {
+		rccs := map[int]CandidateSet{}
+		others := []int{}
+		for _, link := range graph.links[a] {
+			if link.to < a {
+				continue
+			}
+			if rccs[link.to] == nil {
+				others = append(others, link.to)
+			}
+			rccs[link.to] = append(rccs[link.to], link.nr)
+		}
+		for _, b := range others {
+			xs := rccs[b]
+			common := graph.alss[a].cands.Intersect(graph.alss[b].cands)
+			if len(xs) == 1 {
+				eliminated := 0
+				for _, z := range common.Remove(xs) {
+					eliminated += graph.eliminate(game, z, a, b)
+				}
+				if eliminated > 0 {
+					Explain("ALS-XZ: %s and %s, x = %d, eliminating %v", graph.alss[a].ToString1(),
+						graph.alss[b].ToString1(), xs[0], common.Remove(xs))
+					found += eliminated
+				}
+				continue
+			}
+			eliminated := 0
+			for _, x := range xs {
+				eliminated += graph.eliminate(game, x, a, b)
+			}
+			for _, i := range []int{a, b} {
+				for _, nr := range graph.alss[i].cands.Remove(xs) {
+					eliminated += graph.eliminate(game, nr, i)
+				}
+			}
+			if eliminated > 0 {
+				Explain("ALS-XZ with double RCC: %s and %s, x = %v, both are locked",
+					graph.alss[a].ToString1(), graph.alss[b].ToString1(), xs)
+				found += eliminated
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * ALS-XY-Wing: ALSs A and B both have an RCC with the pivot ALS C, x with A
+ * and y with B. If A and B don't have x and y, C is locked without both of
+ * them, which is impossible. So a common candidate z of A and B is in one of
+ * them, and it can be eliminated from the cells seeing all the z cells of
+ * both.
+ */
+func (scanner *Scanner) ScanALSXYWing() int {
+	game := scanner.game
+	graph := game.sharedALSGraph()
+	found := 0
+
+	for c := range graph.alss {
+		for i, la := range graph.links[c] {
+			for _, lb := range graph.links[c][i+1:] {
+				a, b := la.to, lb.to
+				if la.nr == lb.nr || a == b || !graph.disjoint(a, b) {
+					continue
+				}
+				zs := graph.alss[a].cands.Intersect(graph.alss[b].cands).Remove(CandidateSet{la.nr, lb.nr})
+				for _, z := range zs {
+					if n := graph.eliminate(game, z, a, b); n > 0 {
+						Explain("ALS-XY-Wing: pivot %s, %s with x = %d and %s with y = %d, eliminating %d",
+							graph.alss[c].ToString1(), graph.alss[a].ToString1(), la.nr,
+							graph.alss[b].ToString1(), lb.nr, z)
+						found += n
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * ALS chain: ALSs A1...An where each pair of consecutive ALSs has an RCC, and
+ * consecutive RCCs are different. If A1 doesn't have z, it is locked and has
+ * the first RCC, so A2 is locked without it and has the second RCC, and so
+ * on, so An has z. So z can be eliminated from all the cells seeing all the
+ * z cells of A1 and An. The number of ALSs is limited by half the chain
+ * length setting of the game.
+ */
+func (scanner *Scanner) ScanALSChains() int {
+	game := scanner.game
+	graph := game.sharedALSGraph()
+	maxLen := game.ChainLength() / 2
+	found := 0
+
+	type state struct {
+		als  int
+		nr   Num
+		prev int
+	}
+	for start := range graph.alss {
+		// breadth first search over (ALS, RCC used to get there)
+		states := []state{}
+		seen := map[alsLink]bool{}
+		for _, link := range graph.links[start] {
+			states = append(states, state{link.to, link.nr, -1})
+			seen[link] = true
+		}
+		for i := 0; i < len(states); i++ {
+			path := []int{}
+			rccs := CandidateSet{}
+			for j := i; j != -1; j = states[j].prev {
+				path = append([]int{states[j].als}, path...)
+				rccs = append(CandidateSet{states[j].nr}, rccs...)
+			}
+			path = append([]int{start}, path...)
+			if containsInt(path[:len(path)-1], states[i].als) {
+				continue
+			}
+			end := states[i].als
+			if len(path) >= 4 && end > start && graph.disjoint(start, end) {
+				zs := graph.alss[start].cands.Intersect(graph.alss[end].cands)
+				zs = zs.Remove(CandidateSet{rccs[0], rccs[len(rccs)-1]})
+				for _, z := range zs {
+					if n := graph.eliminate(game, z, start, end); n > 0 {
+						strs := make([]string, len(path))
+						for k, als := range path {
+							strs[k] = graph.alss[als].ToString1()
+							if k < len(rccs) {
+								strs[k] += fmt.Sprintf(" -%d-", rccs[k])
+							}
+						}
+						Explain("ALS chain: %s, eliminating %d", strings.Join(strs, " "), z)
+						found += n
+					}
+				}
+			}
+			if len(path) == maxLen {
+				continue
+			}
+			for _, link := range graph.links[end] {
+				if link.nr != states[i].nr && !seen[link] {
+					seen[link] = true
+					states = append(states, state{link.to, link.nr, i})
+				}
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,101 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Depth first search for the solution from the current state of the game,
+ * keeping the candidates eliminated by the logical techniques. The cell with
+ * the fewest candidates left is always tried next (minimum remaining values).
+ * The solution, guesses and backtracks are stored in the result, the game
+ * itself is not changed.
+ */
+func (game *Game) backtrack(result *Result) {
+	board := game.board.clone()
+	// bit k-1 is set if k is possible in a cell or used in a unit
+	var allowed [Y][X]uint16
+	var rows [Y]uint16
+	var cols [X]uint16
+	var boxes [(Y / BoxY) * (X / BoxX)]uint16
+
+	for y := range board {
+		for x, val := range board[y] {
+			if val != 0 {
+				rows[y] |= 1 << (val - 1)
+				cols[x] |= 1 << (val - 1)
+				boxes[getBox(y, x)] |= 1 << (val - 1)
+				continue
+			}
+			for _, nr := range game.poss.Candidates(Num(y), Num(x)) {
+				allowed[y][x] |= 1 << (nr - 1)
+			}
+		}
+	}
+
+	var search func() bool
+	search = func() bool {
+		var best Point
+		var bestMask uint16
+		bestN := NR_MAX + 1
+		for y := 0; y < Y && bestN > 0; y++ {
+			for x := 0; x < X && bestN > 0; x++ {
+				if board[y][x] != 0 {
+					continue
+				}
+				mask := allowed[y][x] &^ (rows[y] | cols[x] | boxes[getBox(y, x)])
+				if n := bitCount(mask); n < bestN {
+					best, bestMask, bestN = Point{x, y}, mask, n
+				}
+			}
+		}
+		if bestN > NR_MAX {
+			// all cells occupied
+			return true
+		}
+		y, x, box := best.y, best.x, getBox(best.y, best.x)
+
+		for nr := Num(1); nr <= NR_MAX; nr++ {
+			bit := uint16(1) << (nr - 1)
+			if bestMask&bit == 0 {
+				continue
+			}
+			if bestN > 1 {
+				result.Guesses++
+				Explain("Search: guessing %d for %s", nr, best.ToString1())
+			}
+			board[y][x] = nr
+			rows[y] |= bit
+			cols[x] |= bit
+			boxes[box] |= bit
+			if search() {
+				return true
+			}
+			board[y][x] = 0
+			rows[y] &^= bit
+			cols[x] &^= bit
+			boxes[box] &^= bit
+			if bestN > 1 {
+				result.Backtracks++
+				Explain("Search: %d for %s leads to a dead end, backtracking", nr, best.ToString1())
+			}
+		}
+		return false
+	}
+
+	if search() {
+		result.Solution = board
+	}
+}
+
+func bitCount(mask uint16) int {
+	n := 0
+	for ; mask != 0; mask &= mask - 1 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,576 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Chains of alternating strong and weak links between nodes. A node is true
+ * if its number is in one of its cells: plain candidates have one cell,
+ * grouped nodes more.
+ * - strong link: at least one of the two nodes is true
+ * - weak link: at most one of the two nodes is true
+ *
+ * A chain starting and ending with a strong link proves that one of its end
+ * nodes is true, so anything weakly linked to both ends is false. A chain
+ * leading from a node back to itself proves it true. If the ends of a chain
+ * are also weakly linked, the chain is a continuous loop, and every weak link
+ * in it turns into a strong one.
+ */
+
+type chainNode struct {
+	nr    Num
+	cells PointSet
+}
+
+func (node chainNode) ToString1() string {
+	if len(node.cells) == 1 {
+		return Candidate{node.cells[0], node.nr}.ToString1()
+	}
+	return fmt.Sprintf("%d[%s]", node.nr, node.cells.ToString1())
+}
+
+/*
+ * Returns true if the node and the candidate can not both be true
+ */
+func (node chainNode) weakTo(cand Candidate) bool {
+	if cand.nr != node.nr {
+		return len(node.cells) == 1 && node.cells[0] == cand.cell
+	}
+	return !node.cells.Contains(cand.cell) && seesAll(cand.cell, node.cells)
+}
+
+/*
+ * Returns true if the two nodes can not both be true
+ */
+func (node chainNode) weakToNode(other chainNode) bool {
+	if node.nr != other.nr {
+		return len(node.cells) == 1 && len(other.cells) == 1 && node.cells[0] == other.cells[0]
+	}
+	for _, cell := range other.cells {
+		if !node.weakTo(Candidate{cell, node.nr}) {
+			return false
+		}
+	}
+	return true
+}
+
+type chainGraph struct {
+	// true if the chains of a single number are to be explained as loops
+	loops  bool
+	nodes  []chainNode
+	ids    map[string]int
+	strong [][]int
+	weak   [][]int
+}
+
+func newChainGraph() *chainGraph {
+	return &chainGraph{ids: map[string]int{}}
+}
+
+/*
+ * Adds the node unless it is already in the graph, returns its id
+ */
+func (graph *chainGraph) addNode(node chainNode) int {
+	key := node.ToString1()
+	if id, ok := graph.ids[key]; ok {
+		return id
+	}
+	id := len(graph.nodes)
+	graph.ids[key] = id
+	graph.nodes = append(graph.nodes, node)
+	graph.strong = append(graph.strong, []int{})
+	graph.weak = append(graph.weak, []int{})
+	return id
+}
+
+func (graph *chainGraph) addStrong(a, b int) {
+	if !containsInt(graph.strong[a], b) {
+		graph.strong[a] = append(graph.strong[a], b)
+		graph.strong[b] = append(graph.strong[b], a)
+	}
+}
+
+/*
+ * Adds weak links between all the nodes for which the filter returns true
+ */
+func (graph *chainGraph) addWeakLinks(filter func(a, b chainNode) bool) {
+	for a, nodeA := range graph.nodes {
+		for b := a + 1; b < len(graph.nodes); b++ {
+			nodeB := graph.nodes[b]
+			if nodeA.weakToNode(nodeB) && filter(nodeA, nodeB) {
+				graph.weak[a] = append(graph.weak[a], b)
+				graph.weak[b] = append(graph.weak[b], a)
+			}
+		}
+	}
+}
+
+/*
+ * Adds nodes for the candidates of nr, and grouped nodes for nr in the box/line
+ * intersections where it is possible in two or more cells
+ */
+func (graph *chainGraph) addDigitNodes(game *Game, nr Num, unitPoss [][][]Point) {
+	for _, cell := range unitPossCells(unitPoss, nr) {
+		graph.addNode(chainNode{nr, PointSet{cell}})
+	}
+	for box := firstBox; box < NrUnits; box++ {
+		for line := 0; line < firstBox; line++ {
+			group := PointSet{}
+			for _, cell := range unitPoss[box][nr-1] {
+				if PointSet(unitPoss[line][nr-1]).Contains(cell) {
+					group = append(group, cell)
+				}
+			}
+			if len(group) >= 2 {
+				graph.addNode(chainNode{nr, group})
+			}
+		}
+	}
+}
+
+/*
+ * Returns all the possible cells of nr in board order
+ */
+func unitPossCells(unitPoss [][][]Point, nr Num) []Point {
+	cells := []Point{}
+	for row := 0; row < Y; row++ {
+		cells = append(cells, unitPoss[row][nr-1]...)
+	}
+	return cells
+}
+
+/*
+ * Adds strong links between the nodes of nr that split the possible cells of
+ * nr in a unit in two
+ */
+func (graph *chainGraph) addUnitStrongLinks(nr Num, unitPoss [][][]Point) {
+	for unit := range unitPoss {
+		cells := PointSet(unitPoss[unit][nr-1])
+		inUnit := []int{}
+		for id, node := range graph.nodes {
+			if node.nr != nr {
+				continue
+			}
+			all := true
+			for _, cell := range node.cells {
+				if !cells.Contains(cell) {
+					all = false
+					break
+				}
+			}
+			if all {
+				inUnit = append(inUnit, id)
+			}
+		}
+		for i, a := range inUnit {
+			for _, b := range inUnit[i+1:] {
+				nodeA, nodeB := graph.nodes[a], graph.nodes[b]
+				if len(nodeA.cells)+len(nodeB.cells) != len(cells) {
+					continue
+				}
+				disjoint := true
+				for _, cell := range nodeA.cells {
+					if nodeB.cells.Contains(cell) {
+						disjoint = false
+						break
+					}
+				}
+				if disjoint {
+					graph.addStrong(a, b)
+				}
+			}
+		}
+	}
+}
+
+/*
+ * Returns the candidates on the board that can not be true together with the
+ * node
+ */
+func weakCandidates(game *Game, node chainNode) []Candidate {
+	cands := []Candidate{}
+	for _, cell := range unsolvedCells(game) {
+		for _, nr := range game.poss.CellCandidates(cell) {
+			cand := Candidate{cell, nr}
+			if node.weakTo(cand) {
+				cands = append(cands, cand)
+			}
+		}
+	}
+	return cands
+}
+
+/*
+ * Returns the nodes of the path in order
+ */
+func (graph *chainGraph) chainNodes(path []int) []chainNode {
+	nodes := make([]chainNode, len(path))
+	for i, id := range path {
+		nodes[i] = graph.nodes[id]
+	}
+	return nodes
+}
+
+/*
+ * Returns the chain as text, with = for strong and - for weak links,
+ * beginning with a strong link
+ */
+func chainString(nodes []chainNode) string {
+	var buffer strings.Builder
+	for i, node := range nodes {
+		if i > 0 {
+			if i%2 == 1 {
+				buffer.WriteString(" = ")
+			} else {
+				buffer.WriteString(" - ")
+			}
+		}
+		buffer.WriteString(node.ToString1())
+	}
+	return buffer.String()
+}
+
+/*
+ * A node of a chain found by the chain techniques: the number and the cells
+ * where it is, more than one for grouped nodes and almost locked sets
+ */
+type ChainNode struct {
+	Nr    Num
+	Cells PointSet
+}
+
+/*
+ * Returns the nodes of the chain or loop that gave the last eliminations of
+ * the chain techniques, in order, nil if there is none. The links between
+ * the nodes alternate, beginning with a strong link.
+ */
+func (game *Game) Chain() []ChainNode {
+	if game.chain == nil {
+		return nil
+	}
+	chain := make([]ChainNode, len(game.chain))
+	for i, node := range game.chain {
+		chain[i] = ChainNode{node.nr, append(PointSet{}, node.cells...)}
+	}
+	return chain
+}
+
+/*
+ * Searches the graph for chains of at most maxLen nodes, and applies the
+ * first one that gives eliminations. Returns the number of eliminations.
+ */
+func (graph *chainGraph) search(game *Game, maxLen int, name string) int {
+	nNodes := len(graph.nodes)
+	// search states: node id * 2, +1 if the node is true
+	parent := make([]int, 2*nNodes)
+	depth := make([]int, 2*nNodes)
+
+	for start := range graph.nodes {
+		if len(graph.strong[start]) == 0 {
+			continue
+		}
+		startWeak := weakCandidates(game, graph.nodes[start])
+		for i := range parent {
+			parent[i] = -1
+		}
+		first := 2 * start
+		parent[first] = first
+		depth[first] = 1
+		queue := []int{first}
+
+		for len(queue) > 0 {
+			state := queue[0]
+			queue = queue[1:]
+			if depth[state] >= maxLen {
+				continue
+			}
+			node, on := state/2, state%2 == 1
+			next := graph.strong[node]
+			if on {
+				next = graph.weak[node]
+			}
+			for _, n := range next {
+				nextState := 2 * n
+				if !on {
+					nextState++
+				}
+				if parent[nextState] != -1 {
+					continue
+				}
+				parent[nextState] = state
+				depth[nextState] = depth[state] + 1
+				queue = append(queue, nextState)
+				if on {
+					continue
+				}
+				// the chain from start to n begins and ends with a strong link
+				path := make([]int, depth[nextState])
+				for s, i := nextState, len(path)-1; i >= 0; s, i = parent[s], i-1 {
+					path[i] = s / 2
+				}
+				if found := graph.conclude(game, path, startWeak, name); found > 0 {
+					return found
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func isSimplePath(path []int) bool {
+	for i, id := range path {
+		if containsInt(path[i+1:], id) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Applies the eliminations of a chain beginning and ending with a strong link
+ */
+func (graph *chainGraph) conclude(game *Game, path []int, startWeak []Candidate, name string) int {
+	start, end := graph.nodes[path[0]], graph.nodes[path[len(path)-1]]
+	chain := graph.chainNodes(path)
+	found := 0
+
+	if path[0] == path[len(path)-1] {
+		// the start node is true
+		if len(start.cells) > 1 && len(startWeak) == 0 {
+			return 0
+		}
+		game.chain = chain
+		Explain("%s, discontinuous loop with two strong links: %s, so %s is true", name,
+			chainString(chain), start.ToString1())
+		if len(start.cells) == 1 {
+			game.Fix(Num(start.cells[0].y), Num(start.cells[0].x), start.nr)
+			return 1
+		}
+		for _, cand := range startWeak {
+			if game.Eliminate(cand.cell, cand.nr) {
+				found++
+			}
+		}
+		return found
+	}
+
+	if len(path) >= 4 && end.weakToNode(start) && isSimplePath(path) {
+		// continuous loop: every weak link in it is strong, so anything
+		// weakly linked to both of its nodes can be eliminated
+		loop := append(path, path[0])
+		cands := []Candidate{}
+		for i := 1; i < len(loop); i += 2 {
+			a, b := graph.nodes[loop[i]], graph.nodes[loop[i+1]]
+			for _, cand := range weakCandidates(game, a) {
+				if b.weakTo(cand) && !graph.inPath(cand, path) {
+					cands = append(cands, cand)
+				}
+			}
+		}
+		if len(cands) > 0 {
+			game.chain = chain
+			Explain("%s, continuous loop: %s - %s", name, chainString(chain), start.ToString1())
+			for _, cand := range cands {
+				if game.Eliminate(cand.cell, cand.nr) {
+					found++
+				}
+			}
+			return found
+		}
+	}
+
+	cands := []Candidate{}
+	for _, cand := range startWeak {
+		if end.weakTo(cand) && game.poss.Get(Num(cand.cell.y), Num(cand.cell.x), cand.nr) {
+			cands = append(cands, cand)
+		}
+	}
+	if len(cands) == 0 {
+		return 0
+	}
+	game.chain = chain
+	if !graph.loops {
+		Explain("%s: %s, eliminating %s", name, chainString(chain), candidatesString(cands))
+	}
+	for _, cand := range cands {
+		if graph.loops {
+			Explain("%s, discontinuous loop with two weak links: %s - %s - %s, so %s is false", name,
+				cand.ToString1(), chainString(chain), cand.ToString1(), cand.ToString1())
+		}
+		if game.Eliminate(cand.cell, cand.nr) {
+			found++
+		}
+	}
+	return found
+}
+
+/*
+ * Returns true if the candidate is a part of any node of the path
+ */
+func (graph *chainGraph) inPath(cand Candidate, path []int) bool {
+	for _, id := range path {
+		node := graph.nodes[id]
+		if node.nr == cand.nr && node.cells.Contains(cand.cell) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * X-Cycles: loops and chains of a single number, with candidates and
+ * box/line groups as nodes
+ */
+func (scanner *Scanner) ScanXCycles() int {
+	game := scanner.game
+	unitPoss := findUnitPossibleCells(game)
+
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		graph := newChainGraph()
+		graph.loops = true
+		graph.addDigitNodes(game, nr, unitPoss)
+		graph.addUnitStrongLinks(nr, unitPoss)
+		graph.addWeakLinks(func(a, b chainNode) bool { return true })
+		if found := graph.search(game, game.ChainLength(), "X-Cycle"); found > 0 {
+			return found
+		}
+	}
+	return 0
+}
+
+/*
+ * Builds a graph of the candidates in the given bivalue cells, with strong
+ * links inside the cells and weak links between the same numbers in cells
+ * seeing each other
+ */
+func bivalueGraph(game *Game, cells []Point) *chainGraph {
+	graph := newChainGraph()
+	for _, cell := range cells {
+		cands := game.poss.CellCandidates(cell)
+		a := graph.addNode(chainNode{cands[0], PointSet{cell}})
+		b := graph.addNode(chainNode{cands[1], PointSet{cell}})
+		graph.addStrong(a, b)
+	}
+	graph.addWeakLinks(func(a, b chainNode) bool { return a.nr == b.nr })
+	return graph
+}
+
+/*
+ * XY-Chains: chains of bivalue cells, where each cell shares a number with
+ * the next one. Remote Pairs, where all the cells have the same two
+ * candidates, are looked for first.
+ */
+func (scanner *Scanner) ScanXYChains() int {
+	game := scanner.game
+	bivalues := cellsWithCandidates(game, 2)
+
+	// remote pairs
+	pairs := []CandidateSet{}
+	for _, cell := range bivalues {
+		cands := game.poss.CellCandidates(cell)
+		known := false
+		for _, pair := range pairs {
+			if pair.Equals(cands) {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+		pairs = append(pairs, cands)
+		cells := []Point{}
+		for _, other := range bivalues {
+			if game.poss.CellCandidates(other).Equals(cands) {
+				cells = append(cells, other)
+			}
+		}
+		if len(cells) < 4 {
+			continue
+		}
+		if found := bivalueGraph(game, cells).search(game, game.ChainLength(), "Remote Pair"); found > 0 {
+			return found
+		}
+	}
+
+	return bivalueGraph(game, bivalues).search(game, game.ChainLength(), "XY-Chain")
+}
+
+/*
+ * Adds a node for each candidate of the ALS, i.e. the cells of the ALS where
+ * the candidate is possible, with strong links between them: if one of the
+ * candidates is not in the ALS, all the others are.
+ */
+func (graph *chainGraph) addALSNodes(game *Game, als ALS) {
+	ids := []int{}
+	for _, nr := range als.cands {
+		ids = append(ids, graph.addNode(chainNode{nr, als.cellsWith(game, nr)}))
+	}
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			graph.addStrong(a, b)
+		}
+	}
+}
+
+/*
+ * Builds the full inference graph of the board: candidates and box/line
+ * groups of all numbers, and optionally the candidates of almost locked sets
+ * as nodes, with strong links in units and bivalue cells
+ */
+func aicGraph(game *Game, withALS bool) *chainGraph {
+	graph := newChainGraph()
+	unitPoss := findUnitPossibleCells(game)
+
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		graph.addDigitNodes(game, nr, unitPoss)
+	}
+	if withALS {
+		for _, als := range findALSs(game) {
+			if len(als.cells) > 1 {
+				graph.addALSNodes(game, als)
+			}
+		}
+	}
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		graph.addUnitStrongLinks(nr, unitPoss)
+	}
+	for _, cell := range cellsWithCandidates(game, 2) {
+		cands := game.poss.CellCandidates(cell)
+		graph.addStrong(graph.addNode(chainNode{cands[0], PointSet{cell}}),
+			graph.addNode(chainNode{cands[1], PointSet{cell}}))
+	}
+	graph.addWeakLinks(func(a, b chainNode) bool { return true })
+	return graph
+}
+
+/*
+ * Alternating inference chains over the full inference graph. X-Cycles,
+ * XY-Chains, W-Wings and many other patterns are special cases of these.
+ */
+func (scanner *Scanner) ScanAIC() int {
+	game := scanner.game
+	return aicGraph(game, false).search(game, game.ChainLength(), "AIC")
+}
+
+/*
+ * Alternating inference chains with almost locked sets as nodes, too, if
+ * enabled with SetALSNodes
+ */
+func (scanner *Scanner) ScanAICWithALS() int {
+	game := scanner.game
+	if !game.alsNodes {
+		return 0
+	}
+	return aicGraph(game, true).search(game, game.ChainLength(), "AIC with ALS nodes")
+}
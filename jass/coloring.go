@@ -0,0 +1,213 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Single digit coloring: the conjugate pairs of a number (units where it is
+ * possible in exactly two cells) form clusters where the cells alternate
+ * between two colors. Either all the cells of one color or all the cells of
+ * the other color are the places for the number.
+ */
+
+type colorCluster [2]PointSet
+
+var colorNames = [2]string{"A", "B"}
+
+/*
+ * Finds the clusters of conjugate pairs of nr, each with at least two cells
+ */
+func findColorClusters(game *Game, nr Num) []colorCluster {
+	unitPoss := findUnitPossibleCells(game)
+	links := map[Point]PointSet{}
+	for unit := range unitPoss {
+		pair := unitPoss[unit][nr-1]
+		if len(pair) != 2 {
+			continue
+		}
+		links[pair[0]] = append(links[pair[0]], pair[1])
+		links[pair[1]] = append(links[pair[1]], pair[0])
+	}
+
+	clusters := []colorCluster{}
+	colored := map[Point]bool{}
+	// walk the cells in board order to get the same clusters every time
+	for _, start := range unsolvedCells(game) {
+		if _, ok := links[start]; !ok || colored[start] {
+			continue
+		}
+		cluster := colorCluster{}
+		color := map[Point]int{start: 0}
+		queue := []Point{start}
+		colored[start] = true
+		for len(queue) > 0 {
+			cell := queue[0]
+			queue = queue[1:]
+			cluster[color[cell]] = append(cluster[color[cell]], cell)
+			for _, next := range links[cell] {
+				if !colored[next] {
+					colored[next] = true
+					color[next] = 1 - color[cell]
+					queue = append(queue, next)
+				}
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+/*
+ * Returns true if the cell sees any of the given cells
+ */
+func seesAny(cell Point, cells []Point) bool {
+	for _, other := range cells {
+		if sees(cell, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cluster colorCluster) contains(cell Point) bool {
+	return cluster[0].Contains(cell) || cluster[1].Contains(cell)
+}
+
+func (cluster colorCluster) explain(name string, nr Num) {
+	Explain("%s for %d: color %s %s, color %s %s", name, nr,
+		colorNames[0], cluster[0].ToString1(), colorNames[1], cluster[1].ToString1())
+}
+
+/*
+ * Simple coloring, looking for
+ * - color wrap: two cells of the same color see each other, so that color is
+ *   false and nr can be eliminated from all its cells
+ * - color trap: a cell outside the cluster sees cells of both colors, so nr
+ *   can be eliminated from it
+ */
+func (scanner *Scanner) ScanSimpleColoring() int {
+	game := scanner.game
+	found := 0
+
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		for _, cluster := range findColorClusters(game, nr) {
+			// color wrap
+			for color, cells := range cluster {
+				wrap := false
+				for i, cell := range cells {
+					if seesAny(cell, cells[i+1:]) {
+						wrap = true
+						break
+					}
+				}
+				if !wrap {
+					continue
+				}
+				cluster.explain("Simple coloring (color wrap)", nr)
+				Explain("Color %s sees itself, eliminating %d from its cells", colorNames[color], nr)
+				for _, cell := range cells {
+					if game.Eliminate(cell, nr) {
+						found++
+					}
+				}
+			}
+			if found > 0 {
+				return found
+			}
+
+			// color trap
+			n := 0
+			for _, cell := range unsolvedCells(game) {
+				if cluster.contains(cell) || !game.poss.Get(Num(cell.y), Num(cell.x), nr) {
+					continue
+				}
+				if seesAny(cell, cluster[0]) && seesAny(cell, cluster[1]) && game.Eliminate(cell, nr) {
+					n++
+				}
+			}
+			if n > 0 {
+				cluster.explain("Simple coloring (color trap)", nr)
+				found += n
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Multi-coloring with two clusters of the same number. If a cell of color
+ * a1 of the first cluster sees a cell of color b1 of the second one, a1 and
+ * b1 can not both be true, so one of their opposite colors a2 and b2 is.
+ * - if a color sees both colors of the other cluster, it is false
+ * - otherwise nr can be eliminated from the cells seeing both a2 and b2
+ */
+func (scanner *Scanner) ScanMultiColoring() int {
+	game := scanner.game
+	found := 0
+
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		clusters := findColorClusters(game, nr)
+		for i, ca := range clusters {
+			for j, cb := range clusters {
+				if i == j {
+					continue
+				}
+				for a := 0; a < 2; a++ {
+					seesColor := [2]bool{}
+					for b := 0; b < 2; b++ {
+						for _, cell := range ca[a] {
+							if seesAny(cell, cb[b]) {
+								seesColor[b] = true
+								break
+							}
+						}
+					}
+					if seesColor[0] && seesColor[1] {
+						n := 0
+						for _, cell := range ca[a] {
+							if game.Eliminate(cell, nr) {
+								n++
+							}
+						}
+						if n > 0 {
+							ca.explain("Multi-coloring (cluster 1)", nr)
+							cb.explain("Multi-coloring (cluster 2)", nr)
+							Explain("Color %s of cluster 1 sees both colors of cluster 2, eliminating %d from its cells",
+								colorNames[a], nr)
+							return found + n
+						}
+						continue
+					}
+					for b := 0; b < 2; b++ {
+						if !seesColor[b] || i > j {
+							continue
+						}
+						// one of the opposite colors is true
+						n := 0
+						for _, cell := range unsolvedCells(game) {
+							if ca.contains(cell) || cb.contains(cell) || !game.poss.Get(Num(cell.y), Num(cell.x), nr) {
+								continue
+							}
+							if seesAny(cell, ca[1-a]) && seesAny(cell, cb[1-b]) && game.Eliminate(cell, nr) {
+								n++
+							}
+						}
+						if n > 0 {
+							ca.explain("Multi-coloring (cluster 1)", nr)
+							cb.explain("Multi-coloring (cluster 2)", nr)
+							Explain("Color %s of cluster 1 sees color %s of cluster 2, eliminating %d from cells seeing both %s of cluster 1 and %s of cluster 2",
+								colorNames[a], colorNames[b], nr, colorNames[1-a], colorNames[1-b])
+							found += n
+						}
+					}
+				}
+			}
+		}
+	}
+	return found
+}
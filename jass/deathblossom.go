@@ -0,0 +1,107 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * Death Blossom: a stem cell with candidates d1...dk, and for each di an ALS
+ * (petal) not containing the stem, whose di cells all see the stem. Whichever
+ * di the stem gets, its petal is locked without di. So if the petals have a
+ * common candidate z that the stem doesn't, z is in one of the petals, and it
+ * can be eliminated from all the cells seeing all the z cells of the petals.
+ *
+ * Stems with two or three candidates are looked for.
+ */
+func (scanner *Scanner) ScanDeathBlossom() int {
+	game := scanner.game
+	graph := game.sharedALSGraph()
+	found := 0
+
+	for _, stem := range append(cellsWithCandidates(game, 2), cellsWithCandidates(game, 3)...) {
+		stemCands := game.poss.CellCandidates(stem)
+		for z := Num(1); z <= NR_MAX; z++ {
+			if stemCands.Contains(z) {
+				continue
+			}
+			// the possible petals for each candidate of the stem
+			petals := make([][]int, len(stemCands))
+			for i, nr := range stemCands {
+				for a, als := range graph.alss {
+					if als.cands.Contains(z) && als.cands.Contains(nr) && !als.cells.Contains(stem) &&
+						seesAll(stem, graph.cells[a][nr-1]) {
+						petals[i] = append(petals[i], a)
+					}
+				}
+			}
+			targets := PointSet{}
+			for _, cell := range unsolvedCells(game) {
+				if cell != stem && game.poss.Get(Num(cell.y), Num(cell.x), z) {
+					targets = append(targets, cell)
+				}
+			}
+			found += scanDeathBlossom(game, graph, stem, stemCands, z, petals, []int{}, targets)
+		}
+	}
+	return found
+}
+
+/*
+ * Chooses a petal for the next candidate of the stem, keeping only the
+ * targets that see all the z cells of the chosen petals
+ */
+func scanDeathBlossom(game *Game, graph *alsGraph, stem Point, stemCands CandidateSet, z Num,
+	petals [][]int, chosen []int, targets PointSet) int {
+
+	i := len(chosen)
+	if i == len(stemCands) {
+		eliminated := 0
+		for _, cell := range targets {
+			if game.Eliminate(cell, z) {
+				eliminated++
+			}
+		}
+		if eliminated > 0 {
+			strs := make([]string, len(chosen))
+			for j, a := range chosen {
+				strs[j] = fmt.Sprintf("%d: %s", stemCands[j], graph.alss[a].ToString1())
+			}
+			Explain("Death Blossom: stem %s %v, petals %s, eliminating %d from %s", stem.ToString1(),
+				stemCands, strings.Join(strs, ", "), z, targets.ToString1())
+		}
+		return eliminated
+	}
+
+	for _, a := range petals[i] {
+		overlap := false
+		for _, b := range chosen {
+			overlap = overlap || !graph.disjoint(a, b)
+		}
+		if overlap {
+			continue
+		}
+		seeing := PointSet{}
+		for _, cell := range targets {
+			if !graph.alss[a].cells.Contains(cell) && seesAll(cell, graph.cells[a][z-1]) {
+				seeing = append(seeing, cell)
+			}
+		}
+		if len(seeing) == 0 {
+			continue
+		}
+		if n := scanDeathBlossom(game, graph, stem, stemCands, z, petals, append(chosen, a), seeing); n > 0 {
+			// the candidates have changed, start over with the next number
+			return n
+		}
+	}
+	return 0
+}
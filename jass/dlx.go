@@ -0,0 +1,228 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import "fmt"
+
+/*
+ * Dancing links (Knuth's algorithm X) over sudoku as an exact cover problem.
+ * There is a column for each constraint: each cell has a number, and each
+ * row, col and box has each number. There is a row for each number in each
+ * cell (only the placed number in occupied cells), covering one of each kind
+ * of constraints. Picking a set of rows that covers each column exactly once
+ * solves the puzzle.
+ *
+ * Much faster than the logical techniques, so it is better suited for
+ * checking that a puzzle has only one solution or for big batches.
+ */
+const (
+	dlxCellCols = X * Y
+	dlxRowCols  = Y * NR_MAX
+	dlxColCols  = X * NR_MAX
+	dlxBoxCols  = (Y / BoxY) * (X / BoxX) * NR_MAX
+	dlxCols     = dlxCellCols + dlxRowCols + dlxColCols + dlxBoxCols
+)
+
+/*
+ * The nodes are stored in slices: node 0 is the root, nodes 1...dlxCols the
+ * column headers and the rest the ones in the rows
+ */
+type dlx struct {
+	left, right, up, down []int
+	col                   []int
+	// the number of nodes in each column, indexed by the header node
+	size []int
+	// the cell and number of the row of each node
+	cell []Point
+	nr   []Num
+
+	chosen     []int
+	guesses    int
+	backtracks int
+}
+
+func newDLX(board Board) *dlx {
+	d := &dlx{size: make([]int, dlxCols+1)}
+	for i := 0; i <= dlxCols; i++ {
+		d.left = append(d.left, (i+dlxCols)%(dlxCols+1))
+		d.right = append(d.right, (i+1)%(dlxCols+1))
+		d.up = append(d.up, i)
+		d.down = append(d.down, i)
+		d.col = append(d.col, i)
+		d.cell = append(d.cell, Point{})
+		d.nr = append(d.nr, 0)
+	}
+	for y := range board {
+		for x, val := range board[y] {
+			for nr := Num(1); nr <= NR_MAX; nr++ {
+				if val != 0 && val != nr {
+					continue
+				}
+				k := int(nr - 1)
+				d.addRow(Point{x, y}, nr, []int{
+					y*X + x,
+					dlxCellCols + y*NR_MAX + k,
+					dlxCellCols + dlxRowCols + x*NR_MAX + k,
+					dlxCellCols + dlxRowCols + dlxColCols + getBox(y, x)*NR_MAX + k,
+				})
+			}
+		}
+	}
+	return d
+}
+
+func (d *dlx) addRow(cell Point, nr Num, cols []int) {
+	first := len(d.left)
+	for i, c := range cols {
+		header := c + 1
+		node := first + i
+		d.left = append(d.left, first+(i+len(cols)-1)%len(cols))
+		d.right = append(d.right, first+(i+1)%len(cols))
+		d.up = append(d.up, d.up[header])
+		d.down = append(d.down, header)
+		d.down[d.up[header]] = node
+		d.up[header] = node
+		d.col = append(d.col, header)
+		d.cell = append(d.cell, cell)
+		d.nr = append(d.nr, nr)
+		d.size[header]++
+	}
+}
+
+func (d *dlx) cover(c int) {
+	d.right[d.left[c]] = d.right[c]
+	d.left[d.right[c]] = d.left[c]
+	for i := d.down[c]; i != c; i = d.down[i] {
+		for j := d.right[i]; j != i; j = d.right[j] {
+			d.down[d.up[j]] = d.down[j]
+			d.up[d.down[j]] = d.up[j]
+			d.size[d.col[j]]--
+		}
+	}
+}
+
+func (d *dlx) uncover(c int) {
+	for i := d.up[c]; i != c; i = d.up[i] {
+		for j := d.left[i]; j != i; j = d.left[j] {
+			d.size[d.col[j]]++
+			d.down[d.up[j]] = j
+			d.up[d.down[j]] = j
+		}
+	}
+	d.right[d.left[c]] = c
+	d.left[d.right[c]] = c
+}
+
+/*
+ * Calls fn with each solution until it returns false. Returns false if
+ * stopped by fn.
+ */
+func (d *dlx) search(fn func(Board) bool) bool {
+	if d.right[0] == 0 {
+		solution := NewBoard()
+		for _, node := range d.chosen {
+			solution[d.cell[node].y][d.cell[node].x] = d.nr[node]
+		}
+		return fn(solution)
+	}
+	// the column with the fewest rows
+	c := d.right[0]
+	for i := d.right[c]; i != 0; i = d.right[i] {
+		if d.size[i] < d.size[c] {
+			c = i
+		}
+	}
+	if d.size[c] == 0 {
+		return true
+	}
+
+	d.cover(c)
+	goOn := true
+	for r := d.down[c]; r != c && goOn; r = d.down[r] {
+		if d.size[c] > 1 {
+			d.guesses++
+		}
+		d.chosen = append(d.chosen, r)
+		for j := d.right[r]; j != r; j = d.right[j] {
+			d.cover(d.col[j])
+		}
+		goOn = d.search(fn)
+		for j := d.left[r]; j != r; j = d.left[j] {
+			d.uncover(d.col[j])
+		}
+		d.chosen = d.chosen[:len(d.chosen)-1]
+		if goOn && d.size[c] > 1 {
+			d.backtracks++
+		}
+	}
+	d.uncover(c)
+	return goOn
+}
+
+/*
+ * Calls fn with each solution of the board until it returns false
+ */
+func (board Board) ForEachSolution(fn func(solution Board) bool) {
+	newDLX(board).search(fn)
+}
+
+/*
+ * Returns the number of solutions of the board, counting at most limit of
+ * them (0 for no limit)
+ */
+func (board Board) CountSolutions(limit int) int {
+	n := 0
+	board.ForEachSolution(func(Board) bool {
+		n++
+		return n != limit
+	})
+	return n
+}
+
+/*
+ * Solves the board with dancing links. Up to two solutions are looked for,
+ * to tell if the solution is unique. No logical techniques are used, so
+ * Logic is left nil.
+ */
+func (board Board) SolveDLX() Result {
+	result := Result{}
+	d := newDLX(board)
+	d.search(func(solution Board) bool {
+		if result.Solution == nil {
+			result.Solution = solution
+		}
+		result.Solutions++
+		return result.Solutions < 2
+	})
+	result.Guesses = d.guesses
+	result.Backtracks = d.backtracks
+	return result
+}
+
+/*
+ * Solve with the dancing links engine, printing the results like the
+ * logical solver
+ */
+func (game *Game) solveDLX() Result {
+	result := game.board.SolveDLX()
+	switch {
+	case !result.Solved():
+		Info("Sudoku has no solution =(")
+		game.board.Print()
+		fmt.Println(game.board.String())
+		return result
+	case result.Solutions > 1:
+		Info("Sudoku solved, but it has more than one solution")
+	default:
+		Info("Sudoku solved!")
+	}
+	result.Solution.Print()
+	fmt.Println(result.Solution.String())
+	return result
+}
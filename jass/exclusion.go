@@ -0,0 +1,157 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Aligned Pair and Triple Exclusion: two or three unoccupied cells in a unit
+ * must get different numbers. A combination of numbers for them is also
+ * excluded if it would leave an almost locked set (including bivalue cells)
+ * with fewer candidates than cells, i.e. if for two of the candidates of the
+ * ALS, all of its cells with the candidate see a cell getting it. A candidate
+ * of a cell that is in none of the remaining combinations can be eliminated.
+ */
+func (scanner *Scanner) ScanAlignedPairExclusion() int {
+	return scanner.scanAlignedExclusion(2, "Aligned Pair Exclusion")
+}
+
+func (scanner *Scanner) ScanAlignedTripleExclusion() int {
+	return scanner.scanAlignedExclusion(3, "Aligned Triple Exclusion")
+}
+
+/*
+ * The cells of each candidate (indices 0...NR_MAX-1) of an ALS
+ */
+type alsMasks struct {
+	size  int
+	cands CandidateSet
+	cells []cellMask
+}
+
+func (scanner *Scanner) scanAlignedExclusion(size int, name string) int {
+	graph := scanner.game.sharedALSGraph()
+	alss := []alsMasks{}
+	for i, als := range graph.alss {
+		masks := alsMasks{len(als.cells), als.cands, make([]cellMask, NR_MAX)}
+		for _, nr := range als.cands {
+			masks.cells[nr-1] = maskOf(graph.cells[i][nr-1]...)
+		}
+		alss = append(alss, masks)
+	}
+	known := map[string]bool{}
+	found := 0
+
+	scanner.ScanAllUnoccupiedGroups(func(game *Game, cells []Point) int {
+		eliminated := 0
+		comb(len(cells), size, func(c []int) {
+			tuple := PointSet{}
+			for _, i := range c {
+				tuple = append(tuple, cells[i])
+			}
+			// the cells in the intersection of a box and a line are
+			// looked at only once
+			key := tuple.ToString1()
+			if known[key] {
+				return
+			}
+			known[key] = true
+			eliminated += scanAlignedTuple(game, tuple, alss, name)
+		})
+		found += eliminated
+		return eliminated
+	}, name)
+
+	return found
+}
+
+func scanAlignedTuple(game *Game, tuple PointSet, alss []alsMasks, name string) int {
+	cands := make([]CandidateSet, len(tuple))
+	peers := make([]cellMask, len(tuple))
+	seen := cellMask{}
+	for i, cell := range tuple {
+		cands[i] = game.poss.CellCandidates(cell)
+		peers[i] = peerMask(cell)
+		seen = seen.union(peers[i])
+	}
+
+	// only the ALSs that two numbers of the tuple could lock up matter
+	relevant := []alsMasks{}
+	for _, als := range alss {
+		n := 0
+		for _, nr := range als.cands {
+			if als.cells[nr-1].subsetOf(seen) {
+				n++
+			}
+		}
+		if n >= 2 {
+			relevant = append(relevant, als)
+		}
+	}
+
+	allowed := make([]CandidateSet, len(tuple))
+	vals := make([]Num, len(tuple))
+	var try func(i int)
+	try = func(i int) {
+		if i == len(tuple) {
+			for _, als := range relevant {
+				if als.excludes(vals, peers) {
+					return
+				}
+			}
+			for j, nr := range vals {
+				allowed[j] = allowed[j].Add(CandidateSet{nr})
+			}
+			return
+		}
+		for _, nr := range cands[i] {
+			if CandidateSet(vals[:i]).Contains(nr) {
+				continue
+			}
+			vals[i] = nr
+			try(i + 1)
+		}
+	}
+	try(0)
+	if len(allowed[0]) == 0 {
+		// no possible combinations, the puzzle is broken
+		return 0
+	}
+
+	found := 0
+	for i, cell := range tuple {
+		excluded := cands[i].Remove(allowed[i])
+		for _, nr := range excluded {
+			game.Eliminate(cell, nr)
+			found++
+		}
+		if len(excluded) > 0 {
+			Explain("%s: %s, eliminating %v from %s", name, tuple.ToString1(), excluded, cell.ToString1())
+		}
+	}
+	return found
+}
+
+/*
+ * Returns true if the numbers for the tuple cells, whose peers are given,
+ * would leave the ALS with fewer candidates than cells
+ */
+func (als alsMasks) excludes(vals []Num, peers []cellMask) bool {
+	left := 0
+	for _, nr := range als.cands {
+		seen := cellMask{}
+		for i, val := range vals {
+			if val == nr {
+				seen = seen.union(peers[i])
+			}
+		}
+		if !als.cells[nr-1].subsetOf(seen) {
+			left++
+		}
+	}
+	return left < als.size
+}
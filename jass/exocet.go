@@ -0,0 +1,265 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Junior Exocet, described here with rows, the same goes for cols:
+ *
+ * - two unoccupied base cells in the same row and box, with three or four
+ *   candidates between them (the base numbers)
+ * - two unoccupied target cells in the other two boxes of the band, on the
+ *   other two rows
+ * - the companion cells, the cells in the cols of the targets on the rows
+ *   that don't have the base cells or the target cell in the same col, don't
+ *   have any base number placed or possible
+ * - the cross lines are the cols of the targets and the third col of the
+ *   base box. Outside the band, each base number must be placed or possible
+ *   in cells of the cross lines that can be covered with two rows or cols.
+ *
+ * Each base number is then in one of the three cross lines inside the band.
+ * If it is in a base cell, it can't be in the base box or on the base row
+ * any more, so it must be in one of the targets. So the targets get the same
+ * numbers as the base cells: other candidates can be eliminated from the
+ * targets, and base numbers not possible in either target from the base.
+ *
+ * The two base cells have different numbers, so each of them is in exactly
+ * one target. Further eliminations follow from that:
+ *
+ * - if a target has only one base number left, it can't be in the other
+ *   target
+ * - outside the band, a base number in a target must be in the other two
+ *   cross lines, on different rows. If no two such cells have it placed or
+ *   possible, it can be eliminated from the base and the targets.
+ *
+ * Only Junior Exocets are looked for, Senior Exocets with the targets
+ * farther away and the rules built on the mirror cells of the targets are
+ * not. Exocets are only looked for if enabled with SetExocet.
+ */
+func (scanner *Scanner) ScanJuniorExocet() int {
+	game := scanner.game
+	if !game.exocet {
+		return 0
+	}
+	found := 0
+	for _, transposed := range []bool{false, true} {
+		found += scanJuniorExocet(game, transposed)
+	}
+	return found
+}
+
+/*
+ * Returns the cell on row r and col c, or col r and row c if transposed
+ */
+func lineCell(transposed bool, r, c int) Point {
+	if transposed {
+		return Point{x: r, y: c}
+	}
+	return Point{x: c, y: r}
+}
+
+/*
+ * Returns the row and col of the cell, or col and row if transposed
+ */
+func lineCoords(transposed bool, cell Point) (int, int) {
+	if transposed {
+		return cell.x, cell.y
+	}
+	return cell.y, cell.x
+}
+
+func scanJuniorExocet(game *Game, transposed bool) int {
+	at := func(r, c int) Point { return lineCell(transposed, r, c) }
+	found := 0
+
+	for band := 0; band < Y/BoxY; band++ {
+		rows := []int{band * BoxY, band*BoxY + 1, band*BoxY + 2}
+		for _, r0 := range rows {
+			for stack := 0; stack < X/BoxX; stack++ {
+				cols := []int{stack * BoxX, stack*BoxX + 1, stack*BoxX + 2}
+				for third, c := range cols {
+					base := PointSet{}
+					for i, col := range cols {
+						if i != third {
+							base = append(base, at(r0, col))
+						}
+					}
+					if game.board.CellOccupied(base[0]) || game.board.CellOccupied(base[1]) {
+						continue
+					}
+					baseCands := candidatesOf(game, base)
+					if len(baseCands) < 3 || len(baseCands) > 4 {
+						continue
+					}
+					found += scanExocetTargets(game, transposed, rows, r0, stack, c, base, baseCands)
+				}
+			}
+		}
+	}
+	return found
+}
+
+func scanExocetTargets(game *Game, transposed bool, rows []int, r0, stack, c int,
+	base PointSet, baseCands CandidateSet) int {
+
+	at := func(r, c int) Point { return lineCell(transposed, r, c) }
+	found := 0
+	others := []int{}
+	for _, r := range rows {
+		if r != r0 {
+			others = append(others, r)
+		}
+	}
+	// the possible targets in the other two boxes of the band
+	targets := [2][]Point{}
+	for i, s := range []int{(stack + 1) % (X / BoxX), (stack + 2) % (X / BoxX)} {
+		for _, r := range others {
+			for col := s * BoxX; col < (s+1)*BoxX; col++ {
+				cell := at(r, col)
+				cands := game.poss.CellCandidates(cell)
+				if !game.board.CellOccupied(cell) && len(cands) >= 2 && len(cands.Intersect(baseCands)) > 0 {
+					targets[i] = append(targets[i], cell)
+				}
+			}
+		}
+	}
+	for _, t1 := range targets[0] {
+		for _, t2 := range targets[1] {
+			r1, c1 := lineCoords(transposed, t1)
+			r2, c2 := lineCoords(transposed, t2)
+			companions := PointSet{}
+			for _, r := range others {
+				if r != r1 {
+					companions = append(companions, at(r, c1))
+				}
+				if r != r2 {
+					companions = append(companions, at(r, c2))
+				}
+			}
+			if hasAnyOf(game, companions, baseCands) {
+				continue
+			}
+			// the cross line cells outside the band where each base number is
+			// placed or possible
+			crossCells := map[Num]PointSet{}
+			cover := true
+			for _, nr := range baseCands {
+				cells := PointSet{}
+				for r := 0; r < Y; r++ {
+					if containsInt(rows, r) {
+						continue
+					}
+					for _, col := range []int{c, c1, c2} {
+						if hasAnyOf(game, PointSet{at(r, col)}, CandidateSet{nr}) {
+							cells = append(cells, at(r, col))
+						}
+					}
+				}
+				crossCells[nr] = cells
+				cover = cover && coverable(cells, 2)
+			}
+			if !cover {
+				continue
+			}
+
+			eliminated := 0
+			for _, target := range []Point{t1, t2} {
+				for _, nr := range game.poss.CellCandidates(target).Remove(baseCands) {
+					if game.Eliminate(target, nr) {
+						eliminated++
+					}
+				}
+			}
+			for _, nr := range baseCands {
+				inTarget := game.poss.CellCandidates(t1).Contains(nr) || game.poss.CellCandidates(t2).Contains(nr)
+				if inTarget && crossPair(crossCells[nr]) {
+					continue
+				}
+				for _, cell := range append(PointSet{t1, t2}, base...) {
+					if game.Eliminate(cell, nr) {
+						eliminated++
+					}
+				}
+			}
+			for i, target := range []Point{t1, t2} {
+				known := game.poss.CellCandidates(target).Intersect(baseCands)
+				if len(known) != 1 {
+					continue
+				}
+				if game.Eliminate([]Point{t2, t1}[i], known[0]) {
+					eliminated++
+				}
+			}
+			if eliminated > 0 {
+				Explain("Junior Exocet: base %s %v, targets %s and %s, companions %s",
+					base.ToString1(), baseCands, t1.ToString1(), t2.ToString1(), companions.ToString1())
+				found += eliminated
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Returns true if any of the numbers is placed or possible in any of the
+ * cells
+ */
+func hasAnyOf(game *Game, cells PointSet, nrs CandidateSet) bool {
+	for _, cell := range cells {
+		if nrs.Contains(game.board[cell.y][cell.x]) {
+			return true
+		}
+		if !game.board.CellOccupied(cell) && len(game.poss.CellCandidates(cell).Intersect(nrs)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Returns true if two of the cells are on different rows and cols
+ */
+func crossPair(cells PointSet) bool {
+	for i, a := range cells {
+		for _, b := range cells[i+1:] {
+			if a.x != b.x && a.y != b.y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+ * Returns true if the cells can be covered with n rows or cols
+ */
+func coverable(cells PointSet, n int) bool {
+	if len(cells) == 0 {
+		return true
+	}
+	if n == 0 {
+		return false
+	}
+	// the first cell must be covered by its row or col
+	first := cells[0]
+	for _, onLine := range []func(Point) bool{
+		func(cell Point) bool { return cell.y == first.y },
+		func(cell Point) bool { return cell.x == first.x },
+	} {
+		rest := PointSet{}
+		for _, cell := range cells {
+			if !onLine(cell) {
+				rest = append(rest, cell)
+			}
+		}
+		if coverable(rest, n-1) {
+			return true
+		}
+	}
+	return false
+}
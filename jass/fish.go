@@ -0,0 +1,317 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Fish of size n: if the possible cells of a number in n base units can all be
+ * covered with n cover units, the number must be placed in the cover units at
+ * those cells, and it can be eliminated from the rest of the cover units.
+ *
+ * Basic fish use rows as base and cols as cover units, or the other way
+ * round.
+ *
+ * Finned fish have extra possible cells (fins) outside the cover units, all
+ * in one box. Either one of the fins is the right place, or the rest is a
+ * basic fish, so the number can be eliminated from the cover units in the box
+ * of the fins. If a base unit would have less than two cells left without the
+ * fins, the fish is called sashimi.
+ *
+ * Franken fish also use boxes as base or cover units, together with either
+ * rows as base and cols as cover units or the other way round. Mutant fish
+ * mix any units. Both are costly to look for, so their size is limited by the
+ * fish size setting of the game, and mutant fish must be enabled separately.
+ */
+
+var fishNames = []string{"", "", "X-Wing", "Swordfish", "Jellyfish"}
+
+func fishName(size int) string {
+	if size < len(fishNames) {
+		return fishNames[size]
+	}
+	return "Fish"
+}
+
+func units(first, n int) []int {
+	res := make([]int, n)
+	for i := range res {
+		res[i] = first + i
+	}
+	return res
+}
+
+func hasUnitKind(units []int, kind int) bool {
+	for _, unit := range units {
+		if unitKind(unit) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Returns "Franken " or "Mutant " depending on the base and cover units, or an
+ * empty string for basic fish
+ */
+func fishKind(base, cover []int) string {
+	if !hasUnitKind(base, unitBox) && !hasUnitKind(cover, unitBox) {
+		if !hasUnitKind(base, unitCol) && !hasUnitKind(cover, unitRow) ||
+			!hasUnitKind(base, unitRow) && !hasUnitKind(cover, unitCol) {
+			return ""
+		}
+		return "Mutant "
+	}
+	if !hasUnitKind(base, unitCol) && !hasUnitKind(cover, unitRow) ||
+		!hasUnitKind(base, unitRow) && !hasUnitKind(cover, unitCol) {
+		return "Franken "
+	}
+	return "Mutant "
+}
+
+func (scanner *Scanner) ScanXWing() int {
+	return scanner.ScanFish(2)
+}
+
+func (scanner *Scanner) ScanSwordfish() int {
+	return scanner.ScanFish(3)
+}
+
+func (scanner *Scanner) ScanJellyfish() int {
+	return scanner.ScanFish(4)
+}
+
+/*
+ * Scans for basic fish of the given size with rows as base and cols as cover
+ * units and vice versa
+ */
+func (scanner *Scanner) ScanFish(size int) int {
+	rows := units(0, Y)
+	cols := units(firstCol, X)
+
+	found := 0
+	found += scanFish(scanner.game, size, rows, cols, false)
+	found += scanFish(scanner.game, size, cols, rows, false)
+	return found
+}
+
+func (scanner *Scanner) ScanFinnedXWing() int {
+	return scanner.ScanFinnedFish(2)
+}
+
+func (scanner *Scanner) ScanFinnedSwordfish() int {
+	return scanner.ScanFinnedFish(3)
+}
+
+func (scanner *Scanner) ScanFinnedJellyfish() int {
+	return scanner.ScanFinnedFish(4)
+}
+
+/*
+ * Scans for finned and sashimi fish of the given size with rows as base and
+ * cols as cover units and vice versa
+ */
+func (scanner *Scanner) ScanFinnedFish(size int) int {
+	rows := units(0, Y)
+	cols := units(firstCol, X)
+
+	found := 0
+	found += scanFish(scanner.game, size, rows, cols, true)
+	found += scanFish(scanner.game, size, cols, rows, true)
+	return found
+}
+
+/*
+ * Possible cells for each number (indices 0...NR_MAX-1) in each unit
+ */
+func findUnitPossibleCells(game *Game) [][][]Point {
+	possCells := make([][][]Point, NrUnits)
+	for unit := range possCells {
+		possCells[unit] = findPossibleCells(game, unitCells(unit))
+	}
+	return possCells
+}
+
+/*
+ * Scans for fish of the given size using the given base and cover units.
+ * If finned is true, only finned fish are looked for.
+ */
+func scanFish(game *Game, size int, baseUnits, coverUnits []int, finned bool) int {
+	if size < MinFishSize || size > MaxFishSize {
+		// the base cells of bigger fish wouldn't fit in the cover masks
+		return 0
+	}
+	found := 0
+	// basic fish are not looked for again when boxes are involved
+	withBoxes := hasUnitKind(baseUnits, unitBox) || hasUnitKind(coverUnits, unitBox)
+	unitPoss := findUnitPossibleCells(game)
+
+	for nr := Num(0); nr < NR_MAX; nr++ {
+		// units where nr still has two or more possible places
+		bases := []int{}
+		for _, unit := range baseUnits {
+			if len(unitPoss[unit][nr]) >= 2 {
+				bases = append(bases, unit)
+			}
+		}
+		if len(bases) < size {
+			continue
+		}
+
+		comb(len(bases), size, func(c []int) {
+			base := make([]int, size)
+			baseCells := PointSet{}
+			for i, b := range c {
+				base[i] = bases[b]
+				for _, cell := range unitPoss[base[i]][nr] {
+					if baseCells.Contains(cell) {
+						// overlapping base units
+						return
+					}
+					baseCells = append(baseCells, cell)
+				}
+			}
+
+			// the cover units must be chosen among the units touching the base
+			// cells; for each such unit, keep a bit mask of the base cells it covers
+			covers := []int{}
+			coverMasks := []uint64{}
+			for _, unit := range coverUnits {
+				if containsInt(base, unit) {
+					continue
+				}
+				mask := uint64(0)
+				for i, cell := range baseCells {
+					if PointSet(unitPoss[unit][nr]).Contains(cell) {
+						mask |= 1 << uint(i)
+					}
+				}
+				if mask != 0 {
+					covers = append(covers, unit)
+					coverMasks = append(coverMasks, mask)
+				}
+			}
+			if len(covers) < size {
+				return
+			}
+			allBase := uint64(1)<<uint(len(baseCells)) - 1
+
+			comb(len(covers), size, func(cc []int) {
+				mask := uint64(0)
+				for _, ci := range cc {
+					mask |= coverMasks[ci]
+				}
+				if (mask != allBase) != finned {
+					return
+				}
+				// the fins must all be in one box
+				finBox := -1
+				fins := PointSet{}
+				for i, cell := range baseCells {
+					if mask&(1<<uint(i)) != 0 {
+						continue
+					}
+					box := getBox(cell.y, cell.x)
+					if finBox != -1 && box != finBox {
+						return
+					}
+					finBox = box
+					fins = append(fins, cell)
+				}
+				cover := make([]int, size)
+				for i, ci := range cc {
+					cover[i] = covers[ci]
+				}
+				kind := fishKind(base, cover)
+				if withBoxes && kind == "" {
+					return
+				}
+				coverCells := PointSet{}
+				for _, unit := range cover {
+					coverCells = append(coverCells, unitPoss[unit][nr]...)
+				}
+
+				eliminated := 0
+				for _, cell := range coverCells {
+					if baseCells.Contains(cell) {
+						continue
+					}
+					if finned && getBox(cell.y, cell.x) != finBox {
+						continue
+					}
+					if game.Eliminate(cell, nr+1) {
+						eliminated++
+					}
+				}
+				if eliminated == 0 {
+					return
+				}
+				found += eliminated
+				if !finned {
+					Explain("%s%s for %d: base %s, cover %s", kind, fishName(size), nr+1, unitsString(base), unitsString(cover))
+					return
+				}
+				fin := "Finned"
+				for _, unit := range base {
+					n := 0
+					for _, cell := range unitPoss[unit][nr] {
+						if coverCells.Contains(cell) {
+							n++
+						}
+					}
+					if n < 2 {
+						fin = "Sashimi"
+					}
+				}
+				Explain("%s %s%s for %d: base %s, cover %s, fins %s", fin, kind, fishName(size), nr+1,
+					unitsString(base), unitsString(cover), fins.ToString1())
+			})
+		})
+	}
+	return found
+}
+
+/*
+ * Scans for basic and finned Franken fish, from X-Wings up to the fish size
+ * of the game
+ */
+func (scanner *Scanner) ScanFrankenFish() int {
+	rowsBoxes := append(units(0, Y), units(firstBox, NrUnits-firstBox)...)
+	colsBoxes := append(units(firstCol, X), units(firstBox, NrUnits-firstBox)...)
+
+	for size := 2; size <= scanner.game.FishSize(); size++ {
+		for _, finned := range []bool{false, true} {
+			found := 0
+			found += scanFish(scanner.game, size, rowsBoxes, colsBoxes, finned)
+			found += scanFish(scanner.game, size, colsBoxes, rowsBoxes, finned)
+			if found > 0 {
+				return found
+			}
+		}
+	}
+	return 0
+}
+
+/*
+ * Scans for basic and finned mutant fish, from X-Wings up to the fish size of
+ * the game, if enabled
+ */
+func (scanner *Scanner) ScanMutantFish() int {
+	if !scanner.game.mutantFish {
+		return 0
+	}
+	all := units(0, NrUnits)
+
+	for size := 2; size <= scanner.game.FishSize(); size++ {
+		for _, finned := range []bool{false, true} {
+			if found := scanFish(scanner.game, size, all, all, finned); found > 0 {
+				return found
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import "fmt"
+
+/*
+ * Forcing chains (nets): assume each alternative in turn on a trial copy of
+ * the game and propagate singles from it. Whatever all the alternatives that
+ * don't end in a contradiction agree on must be true.
+ */
+
+/*
+ * Propagates singles (and the eliminations done while scanning for them) on
+ * a trial copy of the game for at most depth rounds. Returns false if the
+ * game ran into a contradiction.
+ */
+func (game *Game) propagate(depth int) bool {
+	scanner := Scanner{game}
+	quietly(func() {
+		for round := 0; round < depth && !game.contradiction(); round++ {
+			if scanner.ScanSingles()+scanner.ScanSinglesBoxes()+scanner.ScanSinglesRowCol() == 0 {
+				break
+			}
+		}
+	})
+	if game.contradiction() {
+		game.broken = true
+	}
+	return !game.broken
+}
+
+/*
+ * Returns true if nr is placed or still possible in the cell
+ */
+func (game *Game) allows(cell Point, nr Num) bool {
+	val := game.board[cell.y][cell.x]
+	return val == nr || val == 0 && game.poss.Get(Num(cell.y), Num(cell.x), nr)
+}
+
+/*
+ * Applies what all the branches agree on: numbers placed in every branch,
+ * and candidates eliminated in every branch. Branches that ran into a
+ * contradiction are left out. Returns the number of placements and
+ * eliminations.
+ */
+func (game *Game) applyForcing(name string, branches []*Game) int {
+	alive := []*Game{}
+	for _, branch := range branches {
+		if !branch.broken {
+			alive = append(alive, branch)
+		}
+	}
+	if len(alive) == 0 {
+		// all the alternatives fail, the puzzle has no solution
+		return 0
+	}
+
+	places := []Candidate{}
+	elims := []Candidate{}
+	for _, cell := range unsolvedCells(game) {
+		if val := alive[0].board[cell.y][cell.x]; val != 0 {
+			same := true
+			for _, branch := range alive[1:] {
+				if branch.board[cell.y][cell.x] != val {
+					same = false
+					break
+				}
+			}
+			if same {
+				places = append(places, Candidate{cell, val})
+				continue
+			}
+		}
+		for _, nr := range game.poss.CellCandidates(cell) {
+			eliminated := true
+			for _, branch := range alive {
+				if branch.allows(cell, nr) {
+					eliminated = false
+					break
+				}
+			}
+			if eliminated {
+				elims = append(elims, Candidate{cell, nr})
+			}
+		}
+	}
+	if len(places) == 0 && len(elims) == 0 {
+		return 0
+	}
+
+	result := ""
+	if len(places) > 0 {
+		result = "placing " + candidatesString(places)
+	}
+	if len(elims) > 0 {
+		if result != "" {
+			result += " and "
+		}
+		result += "eliminating " + candidatesString(elims)
+	}
+	game.startGuessing()
+	Explain("%s: %d of %d alternatives possible, all %s", name, len(alive), len(branches), result)
+	for _, cand := range elims {
+		game.Eliminate(cand.cell, cand.nr)
+	}
+	for _, cand := range places {
+		if game.board[cand.cell.y][cand.cell.x] == 0 {
+			game.Fix(Num(cand.cell.y), Num(cand.cell.x), cand.nr)
+		}
+	}
+	return len(places) + len(elims)
+}
+
+/*
+ * Tries placing nr in the cell on a trial copy of the game
+ */
+func (game *Game) tryPlace(cell Point, nr Num) *Game {
+	trial := game.trialCopy()
+	trial.Fix(Num(cell.y), Num(cell.x), nr)
+	trial.propagate(game.ForcingDepth())
+	return trial
+}
+
+/*
+ * Cell forcing chains: each candidate of a cell in turn
+ */
+func (scanner *Scanner) ScanCellForcingChains() int {
+	game := scanner.game
+	for _, cell := range unsolvedCells(game) {
+		branches := []*Game{}
+		for _, nr := range game.poss.CellCandidates(cell) {
+			branches = append(branches, game.tryPlace(cell, nr))
+		}
+		if found := game.applyForcing(fmt.Sprintf("Cell forcing chains from %s", cell.ToString1()), branches); found > 0 {
+			return found
+		}
+	}
+	return 0
+}
+
+/*
+ * Unit forcing chains: each possible place of a number in a unit in turn
+ */
+func (scanner *Scanner) ScanUnitForcingChains() int {
+	game := scanner.game
+	unitPoss := findUnitPossibleCells(game)
+	for unit := range unitPoss {
+		for nr, cells := range unitPoss[unit] {
+			if len(cells) < 2 {
+				continue
+			}
+			branches := []*Game{}
+			for _, cell := range cells {
+				branches = append(branches, game.tryPlace(cell, Num(nr+1)))
+			}
+			name := fmt.Sprintf("Unit forcing chains from %d in %s", nr+1, unitName(unit))
+			if found := game.applyForcing(name, branches); found > 0 {
+				return found
+			}
+		}
+	}
+	return 0
+}
+
+/*
+ * Digit forcing chains: a candidate is either true or false
+ */
+func (scanner *Scanner) ScanDigitForcingChains() int {
+	game := scanner.game
+	for _, cell := range unsolvedCells(game) {
+		for _, nr := range game.poss.CellCandidates(cell) {
+			without := game.trialCopy()
+			without.Eliminate(cell, nr)
+			without.propagate(game.ForcingDepth())
+			branches := []*Game{game.tryPlace(cell, nr), without}
+			name := fmt.Sprintf("Digit forcing chains from %s", Candidate{cell, nr}.ToString1())
+			if found := game.applyForcing(name, branches); found > 0 {
+				return found
+			}
+		}
+	}
+	return 0
+}
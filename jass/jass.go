@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -28,6 +29,27 @@ const (
 	NR_MAX     = 9
 	NormalMode = 0
 	StepMode   = 1
+
+	LogicEngine = 0
+	DLXEngine   = 1
+
+	// difficulty ratings of the logical techniques, see Result.Difficulty
+	DifficultyEasy    = 1 // singles, pointing pairs and box/line reduction
+	DifficultyMedium  = 2 // naked and hidden subsets
+	DifficultyHard    = 3 // basic fish, wings, single digit patterns, uniqueness
+	DifficultyExpert  = 4 // coloring, X-Cycles, XY-Chains, complex fish, AIC
+	DifficultyMaster  = 5 // almost locked sets, Sue de Coq, aligned exclusion
+	DifficultyExtreme = 6 // templates, tridagons, Exocets
+	DifficultyNishio  = 7 // trial and error on one number
+	DifficultyForcing = 8 // forcing chains
+
+	DefaultFishSize     = 3
+	MinFishSize         = 2
+	MaxFishSize         = 4
+	DefaultChainLength  = 12
+	MinChainLength      = 2
+	DefaultForcingDepth = 20
+	MinForcingDepth     = 1
 )
 
 type Num uint8
@@ -42,9 +64,73 @@ type PointSet []Point
 type Board [][]Num
 
 type Game struct {
-	board Board
-	poss  Poss
-	mode  int
+	board  Board
+	poss   Poss
+	mode   int
+	engine int
+
+	// settings for the more expensive techniques, zero values mean defaults
+	fishSize     int
+	mutantFish   bool
+	chainLength  int
+	forcingDepth int
+	uniqueness   bool
+	exocet       bool
+	backtracking bool
+	alsNodes     bool
+
+	// trial copies of the game are used for trying out placements, they
+	// don't print anything but mark themselves broken on contradictions
+	trial  bool
+	broken bool
+
+	// the ALS graph shared by the ALS techniques, and the candidates it was
+	// built from
+	alsGraph *alsGraph
+	alsMasks candidateMasks
+
+	// true after trial and error has been marked in the trace, until the
+	// next pattern-based step
+	guessing bool
+
+	// the nodes of the chain that gave the last eliminations, see Chain
+	chain []chainNode
+}
+
+var difficultyNames = []string{"", "easy", "medium", "hard", "expert", "master", "extreme",
+	"Nishio (trial and error)", "forcing chains (trial and error)"}
+
+func DifficultyName(difficulty int) string {
+	if difficulty >= 0 && difficulty < len(difficultyNames) {
+		return difficultyNames[difficulty]
+	}
+	return ""
+}
+
+/*
+ * The result of solving a puzzle. How far the logical techniques got is kept
+ * separate from what was found by searching.
+ */
+type Result struct {
+	// the board as far as the logical techniques got, nil with the DLX
+	// engine that doesn't use them
+	Logic Board
+	// true if the logical techniques solved the puzzle
+	ByLogic bool
+	// the rating of the hardest logical technique used, see the Difficulty
+	// constants, 0 if none made any progress
+	Difficulty int
+	// the full solution, nil if not found
+	Solution Board
+	// the guesses and backtracks needed by searching, if any
+	Guesses    int
+	Backtracks int
+	// the number of solutions, only counted by the DLX engine (up to two)
+	Solutions int
+}
+
+func (result *Result) Solved() bool {
+	return result.Solution != nil
 }
 
 func (set PointSet) Contains(point Point) bool {
@@ -56,6 +142,14 @@ func (set PointSet) Contains(point Point) bool {
 	return false
 }
 
+func (set PointSet) ToString1() string {
+	strs := make([]string, len(set))
+	for i, p := range set {
+		strs[i] = p.ToString1()
+	}
+	return strings.Join(strs, ", ")
+}
+
 func (point Point) ToString() string {
 	return fmt.Sprintf("(%d, %d)", point.x, point.y)
 }
@@ -64,6 +158,20 @@ func (point Point) ToString1() string {
 	return fmt.Sprintf("(%d, %d)", point.x+1, point.y+1)
 }
 
+/*
+ * Returns the col of the point, 0...X-1
+ */
+func (point Point) X() int {
+	return point.x
+}
+
+/*
+ * Returns the row of the point, 0...Y-1
+ */
+func (point Point) Y() int {
+	return point.y
+}
+
 func (a Point) Equals(b Point) bool {
 	return a.x == b.x && a.y == b.y
 }
@@ -81,6 +189,68 @@ func (game *Game) Init() {
 	game.poss = NewPoss()
 }
 
+/*
+ * Returns a trial copy of the game, to try placements on without affecting
+ * the game itself
+ */
+func (game *Game) trialCopy() *Game {
+	trial := *game
+	trial.mode = NormalMode
+	trial.trial = true
+	trial.board = NewBoard()
+	trial.poss = NewPoss()
+	for y := range game.board {
+		copy(trial.board[y], game.board[y])
+		for x := range game.poss[y] {
+			copy(trial.poss[y][x], game.poss[y][x])
+		}
+	}
+	return &trial
+}
+
+/*
+ * Returns true if the game can not be solved any more: a number was placed
+ * on an occupied or impossible cell, an unoccupied cell has no candidates,
+ * or a number is placed twice or has no possible place in some unit
+ */
+func (game *Game) contradiction() bool {
+	if game.broken {
+		return true
+	}
+	for _, cell := range unsolvedCells(game) {
+		if len(game.poss.CellCandidates(cell)) == 0 {
+			return true
+		}
+	}
+	for unit := 0; unit < NrUnits; unit++ {
+		placed := [NR_MAX]int{}
+		possible := [NR_MAX]bool{}
+		for _, cell := range unitCells(unit) {
+			if val := game.board[cell.y][cell.x]; val != 0 {
+				placed[val-1]++
+				continue
+			}
+			for _, nr := range game.poss.CellCandidates(cell) {
+				possible[nr-1] = true
+			}
+		}
+		for nr := range placed {
+			if placed[nr] > 1 || placed[nr] == 0 && !possible[nr] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b Board) clone() Board {
+	clone := NewBoard()
+	for y := range b {
+		copy(clone[y], b[y])
+	}
+	return clone
+}
+
 func (b *Board) Print() {
 	for i := 0; i < X; i++ {
 		if i%BoxY == 0 {
@@ -180,9 +350,15 @@ func (b Board) CellOccupied(cell Point) bool {
 func (game *Game) Fix(y, x, val Num) {
 
 	var i, k Num
-	Explain("Placing %d into (%d, %d)", val, x+1, y+1)
-	if game.board[y][x] != 0 {
-		Info("Error: cell (%d,%d) already contains value %d", x+1, y+1, game.board[y][x])
+	if game.trial {
+		if game.board[y][x] != 0 || !game.poss.Get(y, x, val) {
+			game.broken = true
+		}
+	} else {
+		Explain("Placing %d into (%d, %d)", val, x+1, y+1)
+		if game.board[y][x] != 0 {
+			Info("Error: cell (%d,%d) already contains value %d", x+1, y+1, game.board[y][x])
+		}
 	}
 
 	game.board[y][x] = val
@@ -227,6 +403,19 @@ func (game *Game) Fix(y, x, val Num) {
 	}
 }
 
+/*
+ * Eliminate candidate val from the cell. Returns true if val was possible there
+ */
+func (game *Game) Eliminate(cell Point, val Num) bool {
+	if game.poss.Set(Num(cell.y), Num(cell.x), val, false) {
+		if !game.trial {
+			Debug("Eliminating %d from %s", val, cell.ToString1())
+		}
+		return true
+	}
+	return false
+}
+
 /**
  * Returns number of unsolved cells
  *
@@ -279,21 +468,39 @@ func (board *Board) String() string {
 }
 
 /**
- * Tries to solve the puzzle
+ * Tries to solve the puzzle with the logical techniques, and if they get
+ * stuck, by searching if enabled with SetBacktracking. With the DLX engine,
+ * dancing links are used instead.
  *
- * Returns true if the puzzle was fully solved
+ * Returns the result, see Result. Solve used to return only a bool telling
+ * whether the puzzle was solved, callers should now check Solved, or ByLogic
+ * of the result for a solution found by the logical techniques alone.
  */
-func (game *Game) Solve() bool {
+func (game *Game) Solve() Result {
+	if game.engine == DLXEngine {
+		return game.solveDLX()
+	}
 	nr := 1
+	// the difficulty of the technique tried last, and of the hardest one
+	// that made progress
+	difficulty, hardest := 0, 0
+	game.guessing = false
 	/* loop as long as there is some progress */
 
 	scanner := Scanner{game}
 	for nr > 0 {
+		if difficulty > hardest {
+			hardest = difficulty
+		}
+		if difficulty < DifficultyNishio {
+			game.guessing = false
+		}
 		nr = 0
 		if game.CountUnsolved() == 0 {
 			break
 		}
 
+		difficulty = DifficultyEasy
 		Debug("Scanning for singles...")
 		if nr = scanner.ScanSingles(); nr > 0 {
 			// print_board()
@@ -309,6 +516,7 @@ func (game *Game) Solve() bool {
 			// print_board()
 			continue
 		}
+		difficulty = DifficultyMedium
 		Debug("Scanning for naked pairs...")
 		if nr = scanner.ScanAllGroups(ScanNakedPairsGroup, "naked pairs"); nr > 0 {
 			// print_board()
@@ -319,11 +527,13 @@ func (game *Game) Solve() bool {
 			// print_board();
 			continue
 		}
+		difficulty = DifficultyEasy
 		Debug("Doing box/line reduction...")
 		if nr = scanner.ScanRowsCols(ScanBoxLineGroup, "box/line", true); nr > 0 {
 			//print_board();
 			continue
 		}
+		difficulty = DifficultyMedium
 		Debug("Scanning for naked triples...")
 		if nr = scanner.ScanAllUnoccupiedGroups(ScanNakedTriplesGroup, "naked triples"); nr > 0 {
 			// print_board()
@@ -344,21 +554,296 @@ func (game *Game) Solve() bool {
 			// print_board();
 			continue
 		}
+		difficulty = DifficultyHard
+		Debug("Scanning for X-Wings...")
+		if nr = scanner.ScanXWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for single digit patterns...")
+		if nr = scanner.ScanSingleDigitPatterns(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for swordfish...")
+		if nr = scanner.ScanSwordfish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for jellyfish...")
+		if nr = scanner.ScanJellyfish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for XY-Wings...")
+		if nr = scanner.ScanXYWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for XYZ-Wings...")
+		if nr = scanner.ScanXYZWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for W-Wings...")
+		if nr = scanner.ScanWWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for unique rectangles...")
+		if nr = scanner.ScanUniqueRectangles(); nr > 0 {
+			continue
+		}
+		Debug("Checking for BUG+1...")
+		if nr = scanner.ScanBUG(); nr > 0 {
+			continue
+		}
+		difficulty = DifficultyExpert
+		Debug("Simple coloring...")
+		if nr = scanner.ScanSimpleColoring(); nr > 0 {
+			continue
+		}
+		Debug("Multi-coloring...")
+		if nr = scanner.ScanMultiColoring(); nr > 0 {
+			continue
+		}
+		Debug("3D Medusa...")
+		if nr = scanner.ScanMedusa(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for X-Cycles...")
+		if nr = scanner.ScanXCycles(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for XY-Chains...")
+		if nr = scanner.ScanXYChains(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for finned X-Wings...")
+		if nr = scanner.ScanFinnedXWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for finned swordfish...")
+		if nr = scanner.ScanFinnedSwordfish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for finned jellyfish...")
+		if nr = scanner.ScanFinnedJellyfish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for Franken fish...")
+		if nr = scanner.ScanFrankenFish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for mutant fish...")
+		if nr = scanner.ScanMutantFish(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for alternating inference chains...")
+		if nr = scanner.ScanAIC(); nr > 0 {
+			continue
+		}
+		difficulty = DifficultyMaster
+		Debug("Scanning for ALS-XZ...")
+		if nr = scanner.ScanALSXZ(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for ALS-XY-Wings...")
+		if nr = scanner.ScanALSXYWing(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for ALS chains...")
+		if nr = scanner.ScanALSChains(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for Sue de Coq...")
+		if nr = scanner.ScanSueDeCoq(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for Death Blossoms...")
+		if nr = scanner.ScanDeathBlossom(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for aligned pair exclusion...")
+		if nr = scanner.ScanAlignedPairExclusion(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for aligned triple exclusion...")
+		if nr = scanner.ScanAlignedTripleExclusion(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for alternating inference chains with ALS nodes...")
+		if nr = scanner.ScanAICWithALS(); nr > 0 {
+			continue
+		}
+		difficulty = DifficultyExtreme
+		Debug("Trying templates...")
+		if nr = scanner.ScanTemplates(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for tridagons...")
+		if nr = scanner.ScanTridagon(); nr > 0 {
+			continue
+		}
+		Debug("Scanning for Junior Exocets...")
+		if nr = scanner.ScanJuniorExocet(); nr > 0 {
+			continue
+		}
+		difficulty = DifficultyNishio
+		Debug("Trying Nishio...")
+		if nr = scanner.ScanNishio(); nr > 0 {
+			continue
+		}
+		difficulty = DifficultyForcing
+		Debug("Trying cell forcing chains...")
+		if nr = scanner.ScanCellForcingChains(); nr > 0 {
+			continue
+		}
+		Debug("Trying unit forcing chains...")
+		if nr = scanner.ScanUnitForcingChains(); nr > 0 {
+			continue
+		}
+		Debug("Trying digit forcing chains...")
+		if nr = scanner.ScanDigitForcingChains(); nr > 0 {
+			continue
+		}
 	}
 
+	result := Result{Logic: game.board.clone(), Difficulty: hardest}
 	if nr = game.CountUnsolved(); nr == 0 {
 		Info("Sudoku solved!")
 		game.board.Verify()
+		result.ByLogic = true
+		result.Solution = result.Logic.clone()
 	} else {
 		Info("Sudoku not solved, %d numbers left =(", nr)
 	}
+	if hardest > 0 {
+		Info("Difficulty: %d, %s", hardest, DifficultyName(hardest))
+	}
 	game.board.Print()
 
 	fmt.Println(game.board.String())
 
-	return nr == 0
+	if nr > 0 && game.backtracking {
+		game.backtrack(&result)
+		if result.Solved() {
+			Info("Solved by searching, %d guesses and %d backtracks", result.Guesses, result.Backtracks)
+			result.Solution.Verify()
+			result.Solution.Print()
+			fmt.Println(result.Solution.String())
+		} else {
+			Info("No solution found by searching, %d guesses and %d backtracks", result.Guesses, result.Backtracks)
+		}
+	}
+
+	return result
 }
 
 func (game *Game) SetMode(newmode int) {
 	game.mode = newmode
 }
+
+/*
+ * Sets the engine used by Solve, LogicEngine (default) or DLXEngine
+ */
+func (game *Game) SetEngine(engine int) {
+	game.engine = engine
+}
+
+/*
+ * Returns the board of the game
+ */
+func (game *Game) Board() Board {
+	return game.board
+}
+
+/*
+ * Sets the maximum size of Franken and mutant fish to look for, limited to
+ * MinFishSize...MaxFishSize. Bigger fish would have too many base cells to
+ * keep track of, and they would take forever to look for anyway.
+ */
+func (game *Game) SetFishSize(size int) {
+	switch {
+	case size < MinFishSize:
+		size = MinFishSize
+	case size > MaxFishSize:
+		size = MaxFishSize
+	}
+	game.fishSize = size
+}
+
+func (game *Game) FishSize() int {
+	if game.fishSize == 0 {
+		return DefaultFishSize
+	}
+	return game.fishSize
+}
+
+/*
+ * Enables or disables looking for mutant fish
+ */
+func (game *Game) SetMutantFish(enabled bool) {
+	game.mutantFish = enabled
+}
+
+/*
+ * Enables or disables almost locked sets as nodes of alternating inference
+ * chains, which makes the chains much more costly to look for
+ */
+func (game *Game) SetALSNodes(enabled bool) {
+	game.alsNodes = enabled
+}
+
+/*
+ * Enables or disables the uniqueness techniques, which assume that the
+ * puzzle has only one solution
+ */
+func (game *Game) SetUniqueness(enabled bool) {
+	game.uniqueness = enabled
+}
+
+/*
+ * Enables or disables looking for Exocets, which are costly to look for and
+ * only found in the hardest puzzles
+ */
+func (game *Game) SetExocet(enabled bool) {
+	game.exocet = enabled
+}
+
+/*
+ * Enables or disables searching for the solution when the logical
+ * techniques get stuck
+ */
+func (game *Game) SetBacktracking(enabled bool) {
+	game.backtracking = enabled
+}
+
+/*
+ * Sets the maximum number of rounds of singles to propagate a placement in
+ * forcing chains, at least MinForcingDepth
+ */
+func (game *Game) SetForcingDepth(depth int) {
+	if depth < MinForcingDepth {
+		depth = MinForcingDepth
+	}
+	game.forcingDepth = depth
+}
+
+func (game *Game) ForcingDepth() int {
+	if game.forcingDepth == 0 {
+		return DefaultForcingDepth
+	}
+	return game.forcingDepth
+}
+
+/*
+ * Sets the maximum number of nodes in chains and loops, at least
+ * MinChainLength
+ */
+func (game *Game) SetChainLength(length int) {
+	if length < MinChainLength {
+		length = MinChainLength
+	}
+	game.chainLength = length
+}
+
+func (game *Game) ChainLength() int {
+	if game.chainLength == 0 {
+		return DefaultChainLength
+	}
+	return game.chainLength
+}
@@ -8,7 +8,14 @@
 
 package jass
 
-import "testing"
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestCandidateSet(t *testing.T) {
 
@@ -68,3 +75,1257 @@ func TestPoss(t *testing.T) {
 	}
 	// TODO: Set, Get, GetOnly, Equals...
 }
+
+func newTestGame() *Game {
+	game := &Game{}
+	game.Init()
+	return game
+}
+
+// keepOnly removes candidate nr from all the cells on the given row
+// except the given cols
+func keepOnly(game *Game, nr Num, y int, xs ...int) {
+	for x := 0; x < X; x++ {
+		if !containsInt(xs, x) {
+			game.poss.Set(Num(y), Num(x), nr, false)
+		}
+	}
+}
+
+func TestXWing(t *testing.T) {
+	game := newTestGame()
+	keepOnly(game, 5, 0, 1, 5)
+	keepOnly(game, 5, 3, 1, 5)
+	scanner := Scanner{game}
+	if found := scanner.ScanXWing(); found != 14 {
+		t.Errorf("ScanXWing(): expected 14 eliminations, got %d", found)
+	}
+	for y := 0; y < Y; y++ {
+		want := y == 0 || y == 3
+		if game.poss.Get(Num(y), 1, 5) != want || game.poss.Get(Num(y), 5, 5) != want {
+			t.Errorf("ScanXWing(): wrong candidates for 5 on row %d", y+1)
+		}
+	}
+	if !game.poss.Get(1, 0, 5) {
+		t.Errorf("ScanXWing(): 5 eliminated outside cover cols")
+	}
+}
+
+func TestSwordfish(t *testing.T) {
+	game := newTestGame()
+	keepOnly(game, 3, 0, 0, 3)
+	keepOnly(game, 3, 4, 3, 6)
+	keepOnly(game, 3, 8, 0, 6)
+	scanner := Scanner{game}
+	if found := scanner.ScanXWing(); found != 0 {
+		t.Errorf("ScanXWing(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanSwordfish(); found != 18 {
+		t.Errorf("ScanSwordfish(): expected 18 eliminations, got %d", found)
+	}
+	for y := 0; y < Y; y++ {
+		want := y == 0 || y == 4
+		if game.poss.Get(Num(y), 3, 3) != want {
+			t.Errorf("ScanSwordfish(): wrong candidates for 3 on row %d", y+1)
+		}
+	}
+	if found := scanner.ScanJellyfish(); found != 0 {
+		t.Errorf("ScanJellyfish(): expected no eliminations, got %d", found)
+	}
+}
+
+func TestFinnedXWing(t *testing.T) {
+	// finned and sashimi; the latter has another sashimi with cols 2 and 4
+	for i, row4 := range [][]int{{1, 3, 5}, {1, 3}} {
+		expected := []int{2, 4}[i]
+		game := newTestGame()
+		keepOnly(game, 7, 0, 1, 5)
+		keepOnly(game, 7, 4, row4...)
+		scanner := Scanner{game}
+		if found := scanner.ScanXWing(); found != 0 {
+			t.Errorf("ScanXWing(): expected no eliminations, got %d", found)
+		}
+		if found := scanner.ScanFinnedXWing(); found != expected {
+			t.Errorf("ScanFinnedXWing(): expected %d eliminations, got %d", expected, found)
+		}
+		if game.poss.Get(3, 5, 7) || game.poss.Get(5, 5, 7) || !game.poss.Get(6, 5, 7) {
+			t.Errorf("ScanFinnedXWing(): wrong candidates for 7 on col 6")
+		}
+	}
+}
+
+func TestFrankenFish(t *testing.T) {
+	game := newTestGame()
+	// 2 in box 1 only in (1, 1) and (2, 2), on row 5 only in cols 1 and 2
+	for _, cell := range boxCells(0) {
+		if cell.x != cell.y {
+			game.poss.Set(Num(cell.y), Num(cell.x), 2, false)
+		}
+	}
+	game.poss.Set(2, 2, 2, false)
+	keepOnly(game, 2, 4, 0, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanFish(2); found != 0 {
+		t.Errorf("ScanFish(2): expected no eliminations, got %d", found)
+	}
+	// base row 5 and box 1, cover cols 1 and 2, after which base cols 1 and
+	// 2, cover boxes 1 and 4 eliminate 2 from (3, 4) and (3, 6)
+	if found := scanner.ScanFrankenFish(); found != 12 {
+		t.Errorf("ScanFrankenFish(): expected 12 eliminations, got %d", found)
+	}
+	// 2 is left in cols 1 and 2 only in the base cells
+	for y := 0; y < Y; y++ {
+		for x := 0; x < 2; x++ {
+			base := x == y || y == 4
+			if game.poss.Get(Num(y), Num(x), 2) != base {
+				t.Errorf("ScanFrankenFish(): 2 in (%d, %d): expected %v", x+1, y+1, base)
+			}
+		}
+	}
+	// and elsewhere only from col 3 in box 4
+	for y := 0; y < Y; y++ {
+		for x := 2; x < X; x++ {
+			eliminated := x == 2 && (y == 3 || y == 5)
+			if !game.poss.Get(Num(y), Num(x), 2) && y != 4 && getBox(y, x) != 0 && !eliminated {
+				t.Errorf("ScanFrankenFish(): 2 eliminated from (%d, %d)", x+1, y+1)
+			}
+			if eliminated && game.poss.Get(Num(y), Num(x), 2) {
+				t.Errorf("ScanFrankenFish(): 2 not eliminated from (%d, %d)", x+1, y+1)
+			}
+		}
+	}
+
+	// the fish size is limited
+	game.SetFishSize(8)
+	if game.FishSize() != MaxFishSize {
+		t.Errorf("SetFishSize(8): expected size %d, got %d", MaxFishSize, game.FishSize())
+	}
+	if found := scanner.ScanFish(8); found != 0 {
+		t.Errorf("ScanFish(8): expected no eliminations, got %d", found)
+	}
+}
+
+// setCandidates leaves only the given candidates in the cell (x, y)
+func setCandidates(game *Game, x, y int, cands ...Num) {
+	for k := Num(1); k <= NR_MAX; k++ {
+		game.poss.Set(Num(y), Num(x), k, CandidateSet(cands).Contains(k))
+	}
+}
+
+func TestWings(t *testing.T) {
+	// XY-Wing: pivot (1, 1) {1, 2}, pincers (5, 1) {1, 3} and (1, 5) {2, 3}
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 4, 0, 1, 3)
+	setCandidates(game, 0, 4, 2, 3)
+	scanner := Scanner{game}
+	if found := scanner.ScanXYZWing(); found != 0 {
+		t.Errorf("ScanXYZWing(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanXYWing(); found != 1 {
+		t.Errorf("ScanXYWing(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(4, 4, 3) {
+		t.Errorf("ScanXYWing(): 3 not eliminated from (5, 5)")
+	}
+
+	// XYZ-Wing: pivot (1, 1) {1, 2, 3}, pincers (2, 3) {1, 3} and (5, 1) {2, 3}
+	game = newTestGame()
+	setCandidates(game, 0, 0, 1, 2, 3)
+	setCandidates(game, 1, 2, 1, 3)
+	setCandidates(game, 4, 0, 2, 3)
+	scanner = Scanner{game}
+	if found := scanner.ScanXYZWing(); found != 2 {
+		t.Errorf("ScanXYZWing(): expected 2 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 1, 3) || game.poss.Get(0, 2, 3) || !game.poss.Get(1, 0, 3) {
+		t.Errorf("ScanXYZWing(): wrong candidates for 3 on row 1")
+	}
+}
+
+func TestWWing(t *testing.T) {
+	// (1, 1) and (6, 3) {4, 7} with a strong link on 4 in col 9 between
+	// (9, 1) and (9, 3)
+	game := newTestGame()
+	setCandidates(game, 0, 0, 4, 7)
+	setCandidates(game, 5, 2, 4, 7)
+	for y := 0; y < Y; y++ {
+		if y != 0 && y != 2 {
+			game.poss.Set(Num(y), 8, 4, false)
+		}
+	}
+	scanner := Scanner{game}
+	// 7 from the cells seeing both: (4, 1), (5, 1), (6, 1), (1, 3), (2, 3)
+	// and (3, 3)
+	if found := scanner.ScanWWing(); found != 6 {
+		t.Errorf("ScanWWing(): expected 6 eliminations, got %d", found)
+	}
+	for _, cell := range []Point{{3, 0}, {4, 0}, {5, 0}, {0, 2}, {1, 2}, {2, 2}} {
+		if game.poss.CellCandidates(cell).Contains(7) {
+			t.Errorf("ScanWWing(): 7 not eliminated from %s", cell.ToString1())
+		}
+	}
+	if !game.poss.Get(1, 5, 7) || !game.poss.Get(0, 5, 4) {
+		t.Errorf("ScanWWing(): wrong candidates")
+	}
+}
+
+// keepOnlyCol removes candidate nr from all the cells on the given col
+// except the given rows
+func keepOnlyCol(game *Game, nr Num, x int, ys ...int) {
+	for y := 0; y < Y; y++ {
+		if !containsInt(ys, y) {
+			game.poss.Set(Num(y), Num(x), nr, false)
+		}
+	}
+}
+
+func TestSingleDigitPatterns(t *testing.T) {
+	// Skyscraper: conjugate pairs on rows 1 and 6 with the base on col 1
+	game := newTestGame()
+	keepOnly(game, 6, 0, 0, 4)
+	keepOnly(game, 6, 5, 0, 3)
+	scanner := Scanner{game}
+	if found := scanner.ScanSingleDigitPatterns(); found != 4 {
+		t.Errorf("Skyscraper: expected 4 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 3, 6) || game.poss.Get(4, 4, 6) {
+		t.Errorf("Skyscraper: 6 not eliminated")
+	}
+
+	// Two-String Kite: conjugate pairs on row 2 and col 1 connected in box 1
+	game = newTestGame()
+	keepOnly(game, 6, 1, 1, 6)
+	keepOnlyCol(game, 6, 0, 2, 7)
+	scanner = Scanner{game}
+	if found := scanner.ScanSingleDigitPatterns(); found != 1 {
+		t.Errorf("Two-String Kite: expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(7, 6, 6) {
+		t.Errorf("Two-String Kite: 6 not eliminated from (7, 8)")
+	}
+
+	// Empty Rectangle: 6 in box 1 only on row 1 and col 1, conjugate pair
+	// on col 5 between (5, 1) and (5, 8)
+	game = newTestGame()
+	for _, cell := range boxCells(0) {
+		if cell.x != 0 && cell.y != 0 {
+			game.poss.Set(Num(cell.y), Num(cell.x), 6, false)
+		}
+	}
+	keepOnlyCol(game, 6, 4, 0, 7)
+	scanner = Scanner{game}
+	if found := scanner.ScanSingleDigitPatterns(); found != 1 {
+		t.Errorf("Empty Rectangle: expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(7, 0, 6) {
+		t.Errorf("Empty Rectangle: 6 not eliminated from (1, 8)")
+	}
+}
+
+func TestSimpleColoring(t *testing.T) {
+	// chain (1, 1) - (6, 1) - (6, 5) - (2, 5) traps (2, 2), (2, 3), (1, 4)
+	// and (1, 6)
+	game := newTestGame()
+	keepOnly(game, 5, 0, 0, 5)
+	keepOnlyCol(game, 5, 5, 0, 4)
+	keepOnly(game, 5, 4, 5, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanSimpleColoring(); found != 4 {
+		t.Errorf("ScanSimpleColoring(): expected 4 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 1, 5) || game.poss.Get(2, 1, 5) || game.poss.Get(3, 0, 5) {
+		t.Errorf("ScanSimpleColoring(): 5 not eliminated from col 2")
+	}
+
+	// continuing the chain to (2, 2) wraps color of (1, 1)
+	game = newTestGame()
+	keepOnly(game, 5, 0, 0, 5)
+	keepOnlyCol(game, 5, 5, 0, 4)
+	keepOnly(game, 5, 4, 5, 1)
+	keepOnlyCol(game, 5, 1, 4, 1)
+	scanner = Scanner{game}
+	if found := scanner.ScanSimpleColoring(); found != 3 {
+		t.Errorf("ScanSimpleColoring(): expected 3 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 0, 5) || !game.poss.Get(0, 5, 5) {
+		t.Errorf("ScanSimpleColoring(): wrong color eliminated")
+	}
+}
+
+func TestMultiColoring(t *testing.T) {
+	// clusters (1, 1) - (5, 1) and (2, 2) - (2, 7): (1, 1) sees (2, 2), so
+	// (5, 1) or (2, 7) is 5, and 5 is eliminated from (5, 7)
+	game := newTestGame()
+	keepOnly(game, 5, 0, 0, 4)
+	keepOnlyCol(game, 5, 1, 1, 6)
+	scanner := Scanner{game}
+	found := 0
+	trace := captureTrace(func() {
+		found = scanner.ScanMultiColoring()
+	})
+	if found != 1 {
+		t.Errorf("ScanMultiColoring(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(6, 4, 5) {
+		t.Errorf("ScanMultiColoring(): 5 not eliminated from (5, 7)")
+	}
+	for _, line := range []string{
+		"Multi-coloring (cluster 1) for 5: color A (1, 1), color B (5, 1)",
+		"Multi-coloring (cluster 2) for 5: color A (2, 2), color B (2, 7)",
+		"Color A of cluster 1 sees color A of cluster 2",
+	} {
+		if !strings.Contains(trace, line) {
+			t.Errorf("ScanMultiColoring(): %q not explained, got:\n%s", line, trace)
+		}
+	}
+}
+
+// captureTrace returns the debug output of fn
+func captureTrace(fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	out := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+	stdout, level := os.Stdout, logLevel
+	os.Stdout = w
+	SetLogLevel(LogDebug)
+	fn()
+	SetLogLevel(level)
+	os.Stdout = stdout
+	w.Close()
+	return <-out
+}
+
+func TestMedusa(t *testing.T) {
+	// bivalue cell (1, 1) {1, 2} with conjugate pairs on row 1 for both 1 and
+	// 2 colors both candidates of (8, 1)
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	keepOnly(game, 1, 0, 0, 7)
+	keepOnly(game, 2, 0, 0, 7)
+	scanner := Scanner{game}
+	if found := scanner.ScanMedusa(); found != 7 {
+		t.Errorf("ScanMedusa(): expected 7 eliminations, got %d", found)
+	}
+	if !game.poss.Candidates(0, 7).Equals(CandidateSet{1, 2}) {
+		t.Errorf("ScanMedusa(): wrong candidates %v in (8, 1)", game.poss.Candidates(0, 7))
+	}
+
+	// an unsolved cell without candidates doesn't empty either color
+	game = newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	keepOnly(game, 1, 0, 0, 7)
+	keepOnly(game, 2, 0, 0, 7)
+	setCandidates(game, 8, 8)
+	scanner = Scanner{game}
+	if found := scanner.ScanMedusa(); found != 7 {
+		t.Errorf("ScanMedusa(): expected 7 eliminations, got %d", found)
+	}
+	if !game.poss.Candidates(0, 0).Equals(CandidateSet{1, 2}) {
+		t.Errorf("ScanMedusa(): wrong candidates %v in (1, 1)", game.poss.Candidates(0, 0))
+	}
+}
+
+func TestXCycles(t *testing.T) {
+	// chain 5(1, 1) = 5(6, 1) - 5(6, 5) = 5(2, 5): one of the ends is 5
+	game := newTestGame()
+	keepOnly(game, 5, 0, 0, 5)
+	keepOnlyCol(game, 5, 5, 0, 4)
+	keepOnly(game, 5, 4, 5, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanXCycles(); found != 4 {
+		t.Errorf("ScanXCycles(): expected 4 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 1, 5) || game.poss.Get(5, 0, 5) || !game.poss.Get(6, 0, 5) {
+		t.Errorf("ScanXCycles(): wrong candidates for 5")
+	}
+
+	// too short chain length
+	game = newTestGame()
+	game.SetChainLength(0)
+	if length := game.ChainLength(); length != MinChainLength {
+		t.Errorf("SetChainLength(0): expected length %d, got %d", MinChainLength, length)
+	}
+	game.SetChainLength(3)
+	keepOnly(game, 5, 0, 0, 5)
+	keepOnlyCol(game, 5, 5, 0, 4)
+	keepOnly(game, 5, 4, 5, 1)
+	scanner = Scanner{game}
+	if found := scanner.ScanXCycles(); found != 0 {
+		t.Errorf("ScanXCycles(): expected no eliminations, got %d", found)
+	}
+
+	// grouped node: 5 on row 1 only in (1, 1) and the group (5, 1), (6, 1),
+	// in col 4 only in (4, 2) and (4, 7), which sees the whole group:
+	// 5(1, 1) = 5[(5, 1), (6, 1)] - 5(4, 2) = 5(4, 7)
+	game = newTestGame()
+	keepOnly(game, 5, 0, 0, 4, 5)
+	keepOnlyCol(game, 5, 3, 1, 6)
+	scanner = Scanner{game}
+	if found := scanner.ScanXCycles(); found != 1 {
+		t.Errorf("ScanXCycles(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(6, 0, 5) {
+		t.Errorf("ScanXCycles(): 5 not eliminated from (1, 7)")
+	}
+	if chain := chainText(game.Chain()); chain != "5(1, 1) 5(5, 1), (6, 1) 5(4, 2) 5(4, 7)" {
+		t.Errorf("Chain(): wrong chain %s", chain)
+	}
+
+	// continuous loop: 5 on rows 1 and 5 only in cols 1 and 6, the weak
+	// links in the cols eliminate 5 from the rest of them
+	game = newTestGame()
+	keepOnly(game, 5, 0, 0, 5)
+	keepOnly(game, 5, 4, 0, 5)
+	scanner = Scanner{game}
+	if found := scanner.ScanXCycles(); found != 14 {
+		t.Errorf("ScanXCycles(): expected 14 eliminations, got %d", found)
+	}
+	for y := 0; y < Y; y++ {
+		loop := y == 0 || y == 4
+		if game.poss.Get(Num(y), 0, 5) != loop || game.poss.Get(Num(y), 5, 5) != loop || !game.poss.Get(Num(y), 2, 5) && !loop {
+			t.Errorf("ScanXCycles(): wrong candidates for 5 on row %d", y+1)
+		}
+	}
+	if chain := chainText(game.Chain()); chain != "5(1, 1) 5(6, 1) 5(6, 5) 5(1, 5)" {
+		t.Errorf("Chain(): wrong chain %s", chain)
+	}
+
+	// discontinuous loop with two strong links, with 5 only in (1, 1),
+	// (6, 1), (6, 5), (2, 5), (2, 2), (6, 8) and (2, 8):
+	// 5(1, 1) = 5(6, 1) - 5(6, 5) = 5(2, 5) - 5(2, 2) = 5(1, 1). A shorter
+	// chain would eliminate 5 from (2, 2) first, so the loop is applied
+	// directly.
+	game = newTestGame()
+	fives := []Point{{0, 0}, {5, 0}, {5, 4}, {1, 4}, {1, 1}, {5, 7}, {1, 7}}
+	for y := 0; y < Y; y++ {
+		for x := 0; x < X; x++ {
+			if !PointSet(fives).Contains(Point{x, y}) {
+				game.poss.Set(Num(y), Num(x), 5, false)
+			}
+		}
+	}
+	graph := newChainGraph()
+	graph.loops = true
+	unitPoss := findUnitPossibleCells(game)
+	graph.addDigitNodes(game, 5, unitPoss)
+	graph.addUnitStrongLinks(5, unitPoss)
+	graph.addWeakLinks(func(a, b chainNode) bool { return true })
+	path := []int{}
+	for _, i := range []int{0, 1, 2, 3, 4, 0} {
+		path = append(path, graph.ids[Candidate{fives[i], 5}.ToString1()])
+	}
+	start := graph.nodes[path[0]]
+	if found := graph.conclude(game, path, weakCandidates(game, start), "X-Cycle"); found != 1 {
+		t.Errorf("conclude(): expected 1 placement, got %d", found)
+	}
+	if game.board[0][0] != 5 {
+		t.Errorf("conclude(): 5 not placed in (1, 1)")
+	}
+}
+
+func TestXYChains(t *testing.T) {
+	// 1(1, 1) = 2(1, 1) - 2(6, 1) = 3(6, 1) - 3(6, 5) = 4(6, 5) - 4(2, 5) = 1(2, 5)
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 5, 0, 2, 3)
+	setCandidates(game, 5, 4, 3, 4)
+	setCandidates(game, 1, 4, 4, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanXYChains(); found != 6 {
+		t.Errorf("ScanXYChains(): expected 6 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 1, 1) || game.poss.Get(2, 1, 1) || game.poss.Get(0, 1, 1) || game.poss.Get(4, 0, 1) {
+		t.Errorf("ScanXYChains(): 1 not eliminated")
+	}
+	if chain := chainText(game.Chain()); chain != "1(1, 1) 2(1, 1) 2(6, 1) 3(6, 1) 3(6, 5) 4(6, 5) 4(2, 5) 1(2, 5)" {
+		t.Errorf("Chain(): wrong chain %s", chain)
+	}
+
+	// Remote Pair (1, 1) - (2, 3) - (8, 3) - (8, 6) {1, 2}, with no other 1
+	// or 2 in box 1, row 3 and col 8: (1, 6) sees both ends, and loses 1 and
+	// 2 by two chains
+	game = newTestGame()
+	for _, nr := range []Num{1, 2} {
+		for _, cell := range boxCells(0) {
+			game.poss.Set(Num(cell.y), Num(cell.x), nr, false)
+		}
+		keepOnly(game, nr, 2)
+		keepOnlyCol(game, nr, 7)
+	}
+	for _, cell := range []Point{{0, 0}, {1, 2}, {7, 2}, {7, 5}} {
+		setCandidates(game, cell.x, cell.y, 1, 2)
+	}
+	scanner = Scanner{game}
+	for _, nr := range []Num{1, 2} {
+		if found := scanner.ScanXYChains(); found != 1 {
+			t.Errorf("ScanXYChains(): expected 1 elimination, got %d", found)
+		}
+		left := CandidateSet{3, 4, 5, 6, 7, 8, 9}
+		if nr == 1 {
+			left = left.Add(CandidateSet{2})
+		}
+		if !game.poss.Candidates(5, 0).Equals(left) {
+			t.Errorf("ScanXYChains(): wrong candidates %v in (1, 6)", game.poss.Candidates(5, 0))
+		}
+		want := "1(1, 1) 2(1, 1) 2(2, 3) 1(2, 3) 1(8, 3) 2(8, 3) 2(8, 6) 1(8, 6)"
+		if nr == 2 {
+			want = "2(1, 1) 1(1, 1) 1(2, 3) 2(2, 3) 2(8, 3) 1(8, 3) 1(8, 6) 2(8, 6)"
+		}
+		if chain := chainText(game.Chain()); chain != want {
+			t.Errorf("Chain(): wrong chain %s", chain)
+		}
+	}
+	if found := scanner.ScanXYChains(); found != 0 {
+		t.Errorf("ScanXYChains(): expected no eliminations, got %d", found)
+	}
+}
+
+// chainText lists the nodes of the chain
+func chainText(chain []ChainNode) string {
+	nodes := []string{}
+	for _, node := range chain {
+		nodes = append(nodes, fmt.Sprintf("%d%s", node.Nr, node.Cells.ToString1()))
+	}
+	return strings.Join(nodes, " ")
+}
+
+func TestAIC(t *testing.T) {
+	// the same chain as in TestXYChains
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 5, 0, 2, 3)
+	setCandidates(game, 5, 4, 3, 4)
+	setCandidates(game, 1, 4, 4, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanAIC(); found != 6 {
+		t.Errorf("ScanAIC(): expected 6 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 1, 1) || game.poss.Get(0, 1, 1) || game.poss.Get(4, 0, 1) {
+		t.Errorf("ScanAIC(): 1 not eliminated")
+	}
+
+	// W-Wing of TestWWing
+	game = newTestGame()
+	setCandidates(game, 0, 0, 4, 7)
+	setCandidates(game, 5, 2, 4, 7)
+	keepOnlyCol(game, 4, 8, 0, 2)
+	scanner = Scanner{game}
+	// first 4 is locked in box 3 on col 9
+	if found := scanner.ScanAIC(); found != 6 {
+		t.Errorf("ScanAIC(): expected 6 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 6, 4) || game.poss.Get(2, 7, 4) {
+		t.Errorf("ScanAIC(): 4 not eliminated")
+	}
+	// then 7(1, 1) = 4(1, 1) - 4(9, 1) = 4(9, 3) - 4(6, 3) = 7(6, 3)
+	if found := scanner.ScanAIC(); found != 6 {
+		t.Errorf("ScanAIC(): expected 6 eliminations, got %d", found)
+	}
+	for _, cell := range []Point{{3, 0}, {4, 0}, {5, 0}, {0, 2}, {1, 2}, {2, 2}} {
+		if game.poss.Get(Num(cell.y), Num(cell.x), 7) {
+			t.Errorf("ScanAIC(): 7 not eliminated from %s", cell.ToString1())
+		}
+	}
+	if found := scanner.ScanAIC(); found != 0 {
+		t.Errorf("ScanAIC(): expected no eliminations, got %d", found)
+	}
+}
+
+func TestAICWithALS(t *testing.T) {
+	// ALS {1, 2, 3, 5} on row 1 and ALS {1, 3, 4, 6} on row 5, which have
+	// 1 only in col 1 and 3 only in col 2:
+	// 1(1, 1) = 3(2, 1) - 3(2, 5) = 1(1, 5) - 1(1, 1)
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2, 5)
+	setCandidates(game, 1, 0, 2, 3, 5)
+	setCandidates(game, 2, 0, 2, 5)
+	setCandidates(game, 0, 4, 1, 4, 6)
+	setCandidates(game, 1, 4, 3, 4, 6)
+	setCandidates(game, 2, 4, 4, 6)
+	scanner := Scanner{game}
+	if found := scanner.ScanAIC(); found != 0 {
+		t.Errorf("ScanAIC(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanAICWithALS(); found != 0 {
+		t.Errorf("ScanAICWithALS(): expected no eliminations when not enabled, got %d", found)
+	}
+	game.SetALSNodes(true)
+	if found := scanner.ScanAICWithALS(); found != 14 {
+		t.Errorf("ScanAICWithALS(): expected 14 eliminations, got %d", found)
+	}
+	for y := 0; y < Y; y++ {
+		if y == 0 || y == 4 {
+			continue
+		}
+		if game.poss.Get(Num(y), 0, 1) {
+			t.Errorf("ScanAICWithALS(): 1 not eliminated from (1, %d)", y+1)
+		}
+		if game.poss.Get(Num(y), 1, 3) {
+			t.Errorf("ScanAICWithALS(): 3 not eliminated from (2, %d)", y+1)
+		}
+	}
+}
+
+func TestTrialCopy(t *testing.T) {
+	game := newTestGame()
+	game.ParseBoard("1.......2.9.4...5...6...7...5.9.3.......7.......85..4.7.....6...3...9.8...2.....1")
+	trial := game.trialCopy()
+	nr := game.poss.Candidates(0, 1)[0]
+	trial.Fix(0, 1, nr)
+	if game.board[0][1] != 0 || !game.poss.Get(0, 1, nr) {
+		t.Errorf("trialCopy(): placement on the copy changed the game")
+	}
+	if trial.broken || trial.contradiction() {
+		t.Errorf("trialCopy(): unexpected contradiction")
+	}
+	// 1 is already on row 1
+	trial.Fix(0, 2, 1)
+	if !trial.broken || !trial.contradiction() {
+		t.Errorf("trialCopy(): contradiction not detected")
+	}
+	if game.broken || game.contradiction() {
+		t.Errorf("trialCopy(): contradiction in the game")
+	}
+}
+
+func TestForcingChains(t *testing.T) {
+	// (1, 1) {1, 2}, (2, 1) {1, 2} and (3, 1) {1, 2, 3}: either way 3 goes
+	// to (3, 1), and 1 and 2 leave the rest of row 1 and box 1
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 1, 0, 1, 2)
+	setCandidates(game, 2, 0, 1, 2, 3)
+	scanner := Scanner{game}
+	// 1 placement, 1, 2 and 3 from 6 cells of row 1 and 6 more of box 1, and
+	// 3 from 6 more cells of col 3
+	if found := scanner.ScanCellForcingChains(); found != 43 {
+		t.Errorf("ScanCellForcingChains(): expected 43 results, got %d", found)
+	}
+	if game.board[0][2] != 3 {
+		t.Errorf("ScanCellForcingChains(): 3 not placed in (3, 1)")
+	}
+	if game.poss.Get(0, 3, 1) || game.poss.Get(2, 2, 2) || game.poss.Get(8, 2, 3) || !game.poss.Get(8, 2, 1) {
+		t.Errorf("ScanCellForcingChains(): wrong candidates")
+	}
+
+	// (1, 1) {1, 2}, (2, 1) and (3, 1) {1, 3}: 1 in (1, 1) leaves 3 for both,
+	// so (1, 1) is 2
+	game = newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 1, 0, 1, 3)
+	setCandidates(game, 2, 0, 1, 3)
+	scanner = Scanner{game}
+	// 1 placement, 2 from 6 cells of row 1, 6 more of box 1 and 6 more of
+	// col 1
+	if found := scanner.ScanCellForcingChains(); found != 19 {
+		t.Errorf("ScanCellForcingChains(): expected 19 results, got %d", found)
+	}
+	if game.board[0][0] != 2 || game.poss.Get(8, 0, 2) || !game.poss.Get(8, 0, 1) {
+		t.Errorf("ScanCellForcingChains(): 2 not placed in (1, 1)")
+	}
+}
+
+func TestUnitForcingChains(t *testing.T) {
+	// 3 on row 1 only in (1, 1) and (5, 1), (1, 4) and (5, 4) {3, 7}:
+	// either way (1, 4) and (5, 4) get 3 and 7, and 3 is in cols 1 and 5
+	game := newTestGame()
+	keepOnly(game, 3, 0, 0, 4)
+	setCandidates(game, 0, 3, 3, 7)
+	setCandidates(game, 4, 3, 3, 7)
+	scanner := Scanner{game}
+	// 3 and 7 from 7 cells of row 4, 3 from 7 cells of cols 1 and 5
+	if found := scanner.ScanUnitForcingChains(); found != 28 {
+		t.Errorf("ScanUnitForcingChains(): expected 28 eliminations, got %d", found)
+	}
+	if game.poss.Get(3, 8, 3) || game.poss.Get(3, 8, 7) || game.poss.Get(8, 0, 3) || game.poss.Get(8, 4, 3) {
+		t.Errorf("ScanUnitForcingChains(): 3 and 7 not eliminated")
+	}
+	if !game.poss.Get(0, 0, 3) || !game.poss.Get(8, 1, 3) {
+		t.Errorf("ScanUnitForcingChains(): 3 eliminated from (1, 1) or col 2")
+	}
+}
+
+func TestDigitForcingChains(t *testing.T) {
+	// (1, 1) and (2, 1) {1, 2}: 1 in (1, 1) or not, 1 and 2 are there
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 1, 0, 1, 2)
+	scanner := Scanner{game}
+	// 1 and 2 from 7 cells of row 1 and 6 more of box 1
+	if found := scanner.ScanDigitForcingChains(); found != 26 {
+		t.Errorf("ScanDigitForcingChains(): expected 26 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 8, 1) || game.poss.Get(2, 2, 2) || !game.poss.Get(8, 0, 1) {
+		t.Errorf("ScanDigitForcingChains(): wrong candidates")
+	}
+}
+
+func TestForcingDepth(t *testing.T) {
+	// 2 in (3, 1) leaves 3 for (2, 1) in the first round of singles, and 4
+	// for (1, 1) in the second
+	game := newTestGame()
+	setCandidates(game, 0, 0, 3, 4)
+	setCandidates(game, 1, 0, 2, 3)
+	for depth, want := range []string{"..2", ".32", "432"} {
+		trial := game.trialCopy()
+		trial.Fix(0, 2, 2)
+		trial.propagate(depth)
+		if got := trial.board.String()[:3]; got != want {
+			t.Errorf("propagate(%d): expected %s on row 1, got %s", depth, want, got)
+		}
+	}
+	game.SetForcingDepth(1)
+	if got := game.tryPlace(Point{2, 0}, 2).board.String()[:3]; got != ".32" {
+		t.Errorf("tryPlace(): expected .32 on row 1 with depth 1, got %s", got)
+	}
+	game.SetForcingDepth(0)
+	if depth := game.ForcingDepth(); depth != MinForcingDepth {
+		t.Errorf("SetForcingDepth(0): expected depth %d, got %d", MinForcingDepth, depth)
+	}
+}
+
+func TestNishio(t *testing.T) {
+	// placing 3 into box 1 outside row 2 would leave no place for 3 on row 2
+	game := newTestGame()
+	keepOnly(game, 3, 1, 0, 1)
+	scanner := Scanner{game}
+	if found := scanner.ScanNishio(); found != 6 {
+		t.Errorf("ScanNishio(): expected 6 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 0, 3) || game.poss.Get(2, 2, 3) || !game.poss.Get(0, 3, 3) {
+		t.Errorf("ScanNishio(): wrong candidates for 3 in box 1")
+	}
+	if !game.guessing {
+		t.Errorf("ScanNishio(): trial and error not marked")
+	}
+	if found := scanner.ScanNishio(); found != 0 || !game.guessing {
+		t.Errorf("ScanNishio(): expected no eliminations, got %d", found)
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	// a solution with a few numbers missing needs only singles
+	game := newTestGame()
+	game.ParseBoard("1.......2.9.4...5...6...7...5.9.3.......7.......85..4.7.....6...3...9.8...2.....1")
+	solution := game.Board().SolveDLX().Solution
+	game = newTestGame()
+	game.ParseBoard("..." + solution.String()[3:])
+	result := game.Solve()
+	if !result.ByLogic || result.Difficulty != DifficultyEasy {
+		t.Errorf("Solve(): expected difficulty %d, got %d", DifficultyEasy, result.Difficulty)
+	}
+	if game.guessing {
+		t.Errorf("Solve(): trial and error marked")
+	}
+	result.Solution[0][0] = 0
+	if result.Logic[0][0] == 0 {
+		t.Errorf("Solve(): the solution shares the board of the logic")
+	}
+	if DifficultyName(DifficultyNishio) == "" || DifficultyName(DifficultyForcing+1) != "" {
+		t.Errorf("DifficultyName(): unexpected names")
+	}
+}
+
+func TestUniqueRectangles(t *testing.T) {
+	// type 1: (1, 1), (2, 1) and (1, 4) {1, 2}, roof (2, 4) {1, 2, 3}
+	game := newTestGame()
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 1, 0, 1, 2)
+	setCandidates(game, 0, 3, 1, 2)
+	setCandidates(game, 1, 3, 1, 2, 3)
+	scanner := Scanner{game}
+	if found := scanner.ScanUniqueRectangles(); found != 0 {
+		t.Errorf("ScanUniqueRectangles(): expected nothing without uniqueness, got %d", found)
+	}
+	game.SetUniqueness(true)
+	if found := scanner.ScanUniqueRectangles(); found != 2 {
+		t.Errorf("ScanUniqueRectangles(): expected 2 eliminations, got %d", found)
+	}
+	if !game.poss.CellCandidates(Point{1, 3}).Equals(CandidateSet{3}) {
+		t.Errorf("ScanUniqueRectangles(): wrong candidates for roof cell")
+	}
+
+	// type 4: 1 is possible only in the roof cells on row 4
+	game = newTestGame()
+	game.SetUniqueness(true)
+	setCandidates(game, 0, 0, 1, 2)
+	setCandidates(game, 1, 0, 1, 2)
+	keepOnly(game, 1, 3, 0, 1)
+	scanner = Scanner{game}
+	if found := scanner.ScanUniqueRectangles(); found != 2 {
+		t.Errorf("ScanUniqueRectangles(): expected 2 eliminations, got %d", found)
+	}
+	if game.poss.Get(3, 0, 2) || game.poss.Get(3, 1, 2) {
+		t.Errorf("ScanUniqueRectangles(): 2 not eliminated from roof cells")
+	}
+
+	// the rectangle (1, 1), (2, 1), (1, 4), (2, 4) {1, 2}, with 1 and 2
+	// elsewhere in its units as needed to rule out the other types
+	for _, test := range []struct {
+		kind  string
+		cells []testCell
+		elims []Candidate
+	}{
+		// roof cells on row 4 with the extra 3
+		{"2", []testCell{
+			{0, 0, []Num{1, 2}}, {1, 0, []Num{1, 2}},
+			{0, 3, []Num{1, 2, 3}}, {1, 3, []Num{1, 2, 3}},
+			{4, 3, []Num{3, 4}}, {6, 3, []Num{1, 2}}, {2, 5, []Num{1, 2, 3}},
+		}, []Candidate{{Point{4, 3}, 3}, {Point{2, 5}, 3}}},
+		// the extra 3 and 4 of the roof cells form a naked pair with (5, 4)
+		{"3", []testCell{
+			{0, 0, []Num{1, 2}}, {1, 0, []Num{1, 2}},
+			{0, 3, []Num{1, 2, 3}}, {1, 3, []Num{1, 2, 4}},
+			{4, 3, []Num{3, 4}}, {6, 3, []Num{1, 2, 3, 4}}, {2, 5, []Num{1, 2}},
+		}, []Candidate{{Point{6, 3}, 3}, {Point{6, 3}, 4}}},
+		// diagonal roof cells with the extra 3
+		{"5", []testCell{
+			{0, 0, []Num{1, 2}}, {1, 0, []Num{1, 2, 3}},
+			{0, 3, []Num{1, 2, 3}}, {1, 3, []Num{1, 2}},
+			{6, 0, []Num{1, 2}}, {6, 3, []Num{1, 2}}, {0, 1, []Num{1, 2, 3}},
+		}, []Candidate{{Point{0, 1}, 3}}},
+		// diagonal roof cells, 1 only in the rectangle on rows 1 and 4
+		{"6", []testCell{
+			{0, 0, []Num{1, 2}}, {1, 0, []Num{1, 2, 3}},
+			{0, 3, []Num{1, 2, 4}}, {1, 3, []Num{1, 2}},
+			{6, 0, []Num{2, 5}}, {0, 6, []Num{2, 6}},
+		}, []Candidate{{Point{1, 0}, 1}, {Point{0, 3}, 1}}},
+		// 1 on row 4 and col 2 only in the rectangle
+		{"hidden", []testCell{
+			{0, 0, []Num{1, 2}}, {1, 0, []Num{1, 2, 3}},
+			{0, 3, []Num{1, 2, 4}}, {1, 3, []Num{1, 2, 5}},
+			{6, 3, []Num{2, 6}},
+		}, []Candidate{{Point{1, 3}, 2}}},
+	} {
+		game = newSparseTestGame(test.cells)
+		scanner = Scanner{game}
+		if found := scanner.ScanUniqueRectangles(); found != 0 {
+			t.Errorf("ScanUniqueRectangles(): type %s: expected nothing without uniqueness, got %d", test.kind, found)
+		}
+		game.SetUniqueness(true)
+		if found := scanner.ScanUniqueRectangles(); found != len(test.elims) {
+			t.Errorf("ScanUniqueRectangles(): type %s: expected %d eliminations, got %d", test.kind, len(test.elims), found)
+		}
+		for _, cand := range test.elims {
+			if game.poss.CellCandidates(cand.cell).Contains(cand.nr) {
+				t.Errorf("ScanUniqueRectangles(): type %s: %s not eliminated", test.kind, cand.ToString1())
+			}
+		}
+	}
+}
+
+// newFilledTestGame returns a game with all the cells occupied (by bogus
+// numbers), so that only the cells emptied by the test are considered
+func newFilledTestGame() *Game {
+	game := newTestGame()
+	for y := 0; y < Y; y++ {
+		for x := 0; x < X; x++ {
+			game.board[y][x] = 9
+			setCandidates(game, x, y)
+		}
+	}
+	return game
+}
+
+type testCell struct {
+	x, y  int
+	cands []Num
+}
+
+// newSparseTestGame returns a filled game with the given cells emptied and
+// only the given candidates left in them
+func newSparseTestGame(cells []testCell) *Game {
+	game := newFilledTestGame()
+	for _, c := range cells {
+		game.board[c.y][c.x] = 0
+		setCandidates(game, c.x, c.y, c.cands...)
+	}
+	return game
+}
+
+func TestBUG(t *testing.T) {
+	// only a rectangle of {1, 2} cells is left, with an extra 3 in (2, 4)
+	game := newFilledTestGame()
+	for _, cell := range []Point{{0, 0}, {1, 0}, {0, 3}, {1, 3}} {
+		game.board[cell.y][cell.x] = 0
+		setCandidates(game, cell.x, cell.y, 1, 2)
+	}
+	setCandidates(game, 1, 3, 1, 2, 3)
+	scanner := Scanner{game}
+	if found := scanner.ScanBUG(); found != 0 {
+		t.Errorf("ScanBUG(): expected nothing without uniqueness, got %d", found)
+	}
+	game.SetUniqueness(true)
+	if found := scanner.ScanBUG(); found != 1 || game.board[3][1] != 3 {
+		t.Errorf("ScanBUG(): expected 3 placed into (2, 4), got %d", game.board[3][1])
+	}
+}
+
+func TestALS(t *testing.T) {
+	// ALS-XZ: (1, 1) {1, 2} and (5, 1), (5, 2) {1, 2, 3} with RCC 1, so 2 is
+	// in (1, 1) or (5, 2)
+	game := newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 2}},
+		{4, 0, []Num{1, 3}},
+		{4, 1, []Num{2, 3}},
+		{1, 1, []Num{2, 5}},
+		{3, 0, []Num{2, 6}},
+	})
+	scanner := Scanner{game}
+	if found := scanner.ScanALSXZ(); found != 2 {
+		t.Errorf("ScanALSXZ(): expected 2 eliminations, got %d", found)
+	}
+	if game.poss.Get(1, 1, 2) || game.poss.Get(0, 3, 2) {
+		t.Errorf("ScanALSXZ(): 2 not eliminated")
+	}
+	if !game.poss.Get(0, 0, 2) || !game.poss.Get(1, 4, 2) {
+		t.Errorf("ScanALSXZ(): 2 eliminated from the ALSs")
+	}
+
+	// double RCC: (1, 1), (5, 1) {1, 3, 4} and (1, 2), (4, 2) {2, 3, 4}
+	// with RCCs 4 in col 1 and 3 in box 2, so 1 is locked on row 1, 2 on
+	// row 2, 4 in col 1 and 3 in box 2
+	game = newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 4}},
+		{4, 0, []Num{1, 3}},
+		{0, 1, []Num{2, 4}},
+		{3, 1, []Num{2, 3}},
+		{1, 0, []Num{1, 5}},
+		{6, 1, []Num{2, 8}},
+		{2, 2, []Num{4, 6}},
+		{5, 2, []Num{3, 7}},
+	})
+	scanner = Scanner{game}
+	if found := scanner.ScanALSXZ(); found != 4 {
+		t.Errorf("ScanALSXZ(): expected 4 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 1, 1) || game.poss.Get(1, 6, 2) || game.poss.Get(2, 2, 4) || game.poss.Get(2, 5, 3) {
+		t.Errorf("ScanALSXZ(): wrong candidates left with double RCC")
+	}
+
+	// ALS-XY-Wing: pivot (5, 5) {1, 2}, (5, 1), (6, 1) {1, 3, 6} with x = 1
+	// and (1, 5) {2, 3} with y = 2, so 3 is in (5, 1), (6, 1) or (1, 5)
+	game = newSparseTestGame([]testCell{
+		{4, 4, []Num{1, 2}},
+		{4, 0, []Num{1, 3}},
+		{5, 0, []Num{3, 6}},
+		{0, 4, []Num{2, 3}},
+		{0, 0, []Num{3, 4}},
+	})
+	scanner = Scanner{game}
+	if found := scanner.ScanALSXZ(); found != 0 {
+		t.Errorf("ScanALSXZ(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanALSXYWing(); found != 1 {
+		t.Errorf("ScanALSXYWing(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(0, 0, 3) {
+		t.Errorf("ScanALSXYWing(): 3 not eliminated from (1, 1)")
+	}
+
+	// ALS chain: (1, 1) {1, 5} -1- (5, 1) {1, 2} -2- (5, 5) {2, 3} -3-
+	// (1, 5) {3, 5}, so 5 is in (1, 1) or (1, 5)
+	game = newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 5}},
+		{4, 0, []Num{1, 2}},
+		{4, 4, []Num{2, 3}},
+		{0, 4, []Num{3, 5}},
+		{0, 2, []Num{5, 6}},
+	})
+	scanner = Scanner{game}
+	if found := scanner.ScanALSXZ() + scanner.ScanALSXYWing(); found != 0 {
+		t.Errorf("ScanALSXZ(), ScanALSXYWing(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanALSChains(); found != 1 {
+		t.Errorf("ScanALSChains(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(2, 0, 5) {
+		t.Errorf("ScanALSChains(): 5 not eliminated from (1, 3)")
+	}
+}
+
+func TestALSGraphShared(t *testing.T) {
+	game := newSparseTestGame([]testCell{{0, 0, []Num{1, 2}}, {4, 0, []Num{1, 3}}})
+	graph := game.sharedALSGraph()
+	if game.sharedALSGraph() != graph {
+		t.Errorf("sharedALSGraph(): built again without changes")
+	}
+	game.Eliminate(Point{4, 0}, 3)
+	var rebuilt *alsGraph
+	// finding the ALSs is not a technique of its own, so nothing is traced
+	if trace := captureTrace(func() { rebuilt = game.sharedALSGraph() }); trace != "" {
+		t.Errorf("sharedALSGraph(): unexpected trace %q", trace)
+	}
+	if rebuilt == graph {
+		t.Errorf("sharedALSGraph(): not built again after an elimination")
+	}
+}
+
+func TestSueDeCoq(t *testing.T) {
+	// (1, 1), (2, 1) {1, 2, 3, 4} in row 1 and box 1, (5, 1) {1, 3} in the
+	// row and (1, 2) {2, 4} in the box
+	game := newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 2, 3}},
+		{1, 0, []Num{1, 2, 4}},
+		{4, 0, []Num{1, 3}},
+		{0, 1, []Num{2, 4}},
+		{7, 0, []Num{1, 5}},
+		{2, 2, []Num{4, 6}},
+	})
+	scanner := Scanner{game}
+	if found := scanner.ScanSueDeCoq(); found != 2 {
+		t.Errorf("ScanSueDeCoq(): expected 2 eliminations, got %d", found)
+	}
+	if game.poss.Get(0, 7, 1) || game.poss.Get(2, 2, 4) {
+		t.Errorf("ScanSueDeCoq(): wrong candidates left")
+	}
+}
+
+func TestDeathBlossom(t *testing.T) {
+	// stem (1, 1) {1, 2}, petals (4, 1) {1, 3} and (1, 4) {2, 3}
+	game := newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 2}},
+		{3, 0, []Num{1, 3}},
+		{0, 3, []Num{2, 3}},
+		{3, 3, []Num{3, 4}},
+	})
+	scanner := Scanner{game}
+	if found := scanner.ScanDeathBlossom(); found != 1 {
+		t.Errorf("ScanDeathBlossom(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(3, 3, 3) {
+		t.Errorf("ScanDeathBlossom(): 3 not eliminated from (4, 4)")
+	}
+}
+
+func TestAlignedPairExclusion(t *testing.T) {
+	// (1, 1) {1, 2} and (2, 1) {3, 4}: 1 and 3 would leave no candidates for
+	// (5, 1) {1, 3}, 1 and 4 for (7, 1) {1, 4}
+	game := newSparseTestGame([]testCell{
+		{0, 0, []Num{1, 2}},
+		{1, 0, []Num{3, 4}},
+		{4, 0, []Num{1, 3}},
+		{6, 0, []Num{1, 4}},
+	})
+	scanner := Scanner{game}
+	if found := scanner.ScanAlignedPairExclusion(); found != 1 {
+		t.Errorf("ScanAlignedPairExclusion(): expected 1 elimination, got %d", found)
+	}
+	for x, cands := range map[int]CandidateSet{0: {2}, 1: {3, 4}, 4: {1, 3}, 6: {1, 4}} {
+		if !game.poss.Candidates(0, Num(x)).Equals(cands) {
+			t.Errorf("ScanAlignedPairExclusion(): expected %v in (%d, 1), got %v", cands, x+1, game.poss.Candidates(0, Num(x)))
+		}
+	}
+}
+
+func TestAlignedTripleExclusion(t *testing.T) {
+	// (6, 1) = 5 would force (4, 1) = 4 and (4, 2) = 6 in box 2, which leave
+	// the ALS (1, 1), (1, 2) {2, 4, 6} only 2. No pair of the cells excludes
+	// it.
+	game := newSparseTestGame([]testCell{
+		{0, 0, []Num{2, 4}},
+		{1, 0, []Num{1, 2, 6}},
+		{3, 0, []Num{4, 5}},
+		{5, 0, []Num{2, 3, 5}},
+		{0, 1, []Num{2, 6}},
+		{3, 1, []Num{4, 5, 6}},
+		{1, 2, []Num{1, 5, 6}},
+		{4, 2, []Num{1, 2, 4}},
+	})
+	scanner := Scanner{game}
+	if found := scanner.ScanAlignedPairExclusion(); found != 0 {
+		t.Errorf("ScanAlignedPairExclusion(): expected no eliminations, got %d", found)
+	}
+	if found := scanner.ScanAlignedTripleExclusion(); found != 1 {
+		t.Errorf("ScanAlignedTripleExclusion(): expected 1 elimination, got %d", found)
+	}
+	if game.poss.Get(0, 5, 5) {
+		t.Errorf("ScanAlignedTripleExclusion(): 5 not eliminated from (6, 1)")
+	}
+}
+
+func TestJuniorExocet(t *testing.T) {
+	// base (6, 7) {3, 5}, (6, 9) {1, 3} in col 6, targets (5, 2) {1, 3, 5}
+	// and (4, 6) {3, 4}, cross lines rows 2, 6 and 8
+	game := newTestGame()
+	game.ParseBoard("1.4879...8.62..49.795..412.91.5..364.621...595.8.96...2...8...16817.2.4347.9...85")
+	scanner := Scanner{game}
+	if found := scanner.ScanJuniorExocet(); found != 0 {
+		t.Errorf("ScanJuniorExocet(): expected nothing when not enabled, got %d", found)
+	}
+	game.SetExocet(true)
+	if found := scanner.ScanJuniorExocet(); found != 4 {
+		t.Errorf("ScanJuniorExocet(): expected 4 eliminations, got %d", found)
+	}
+	// 4 is not a base number, 5 is only in row 6 outside the band, and with
+	// 3 the only base number left in (4, 6), it can't be in (5, 2)
+	for _, cand := range []Candidate{{Point{3, 5}, 4}, {Point{4, 1}, 5}, {Point{5, 6}, 5}, {Point{4, 1}, 3}} {
+		if game.poss.CellCandidates(cand.cell).Contains(cand.nr) {
+			t.Errorf("ScanJuniorExocet(): expected %s to be eliminated", cand.ToString1())
+		}
+	}
+	if cands := game.poss.CellCandidates(Point{4, 1}); !cands.Equals(CandidateSet{1}) {
+		t.Errorf("ScanJuniorExocet(): expected only 1 left in (5, 2), got %v", cands)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	// 1 only in cols 1 and 4 on rows 1 and 2: all templates use them, and
+	// row 3 has 1 in box 3
+	game := newTestGame()
+	keepOnly(game, 1, 0, 0, 3)
+	keepOnly(game, 1, 1, 0, 3)
+	scanner := Scanner{game}
+	if found := scanner.ScanTemplates(); found != 18 {
+		t.Errorf("ScanTemplates(): expected 18 eliminations, got %d", found)
+	}
+	if game.poss.Get(5, 0, 1) || game.poss.Get(2, 4, 1) || !game.poss.Get(2, 6, 1) {
+		t.Errorf("ScanTemplates(): wrong candidates for 1")
+	}
+
+	// a template overlapping the only template of another number is dropped,
+	// also when there are too many to compare one by one
+	a, b := maskOf(Point{0, 0}), maskOf(Point{1, 0})
+	for _, n := range []int{1, maxCrossTemplates + 1} {
+		templates := [][]cellMask{{a, b}, {}}
+		for i := 0; i < n; i++ {
+			templates[1] = append(templates[1], a)
+		}
+		crossCheckTemplates(templates)
+		if len(templates[0]) != 1 || templates[0][0] != b || len(templates[1]) != n {
+			t.Errorf("crossCheckTemplates(): wrong templates left with %d templates", n)
+		}
+	}
+}
+
+func BenchmarkTemplates(b *testing.B) {
+	for _, puzzle := range []string{
+		"1.......2.9.4...5...6...7...5.9.3.......7.......85..4.7.....6...3...9.8...2.....1",
+		"1.......2.9.4...5...6...7........................................................",
+	} {
+		game := newTestGame()
+		game.ParseBoard(puzzle)
+		b.Run(fmt.Sprintf("%d unsolved", game.CountUnsolved()), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanner := Scanner{game.trialCopy()}
+				scanner.ScanTemplates()
+			}
+		})
+	}
+}
+
+func TestTridagon(t *testing.T) {
+	// three of the boxes on the main diagonal and one on the anti-diagonal
+	// can't get only three numbers, all on the same diagonal can
+	shape := [4]int{0, 0, 0, 5}
+	if tridagonFits(shape) || !tridagonFits([4]int{0, 0, 0, 0}) {
+		t.Errorf("tridagonFits(): wrong shapes")
+	}
+
+	// {1, 2, 3} in the shape in boxes 1, 2, 4 and 5, with an extra 4 in one
+	// cell
+	game := newFilledTestGame()
+	scanner := Scanner{game}
+	corners := [4][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	for j, corner := range corners {
+		for k := 0; k < 3; k++ {
+			x, y := corner[1]*BoxX+perms3[shape[j]][k], corner[0]*BoxY+k
+			game.board[y][x] = 0
+			setCandidates(game, x, y, 1, 2, 3)
+		}
+	}
+	guardian := Point{perms3[shape[0]][0], 0}
+	setCandidates(game, guardian.x, guardian.y, 1, 2, 3, 4)
+	if found := scanner.ScanTridagon(); found != 3 {
+		t.Errorf("ScanTridagon(): expected 3 eliminations, got %d", found)
+	}
+	if !game.poss.CellCandidates(guardian).Equals(CandidateSet{4}) {
+		t.Errorf("ScanTridagon(): wrong candidates for the guardian cell")
+	}
+}
+
+func TestBacktrack(t *testing.T) {
+	// an empty board has lots of solutions, one of them is found
+	game := newTestGame()
+	result := Result{}
+	game.backtrack(&result)
+	if !result.Solved() || !result.Solution.Verify() || result.Guesses == 0 {
+		t.Errorf("backtrack(): no solution found for an empty board")
+	}
+	if result.ByLogic || game.CountUnsolved() != X*Y {
+		t.Errorf("backtrack(): the game or the logic result was changed")
+	}
+
+	// no solution when 1 is not possible on the first row
+	game = newTestGame()
+	keepOnly(game, 1, 0)
+	result = Result{}
+	game.backtrack(&result)
+	if result.Solved() || result.Backtracks == 0 {
+		t.Errorf("backtrack(): a solution found for an impossible board")
+	}
+}
+
+func TestDLX(t *testing.T) {
+	game := newTestGame()
+	game.ParseBoard("1.......2.9.4...5...6...7...5.9.3.......7.......85..4.7.....6...3...9.8...2.....1")
+	board := game.Board()
+	result := board.SolveDLX()
+	if !result.Solved() || result.Solutions != 1 || result.ByLogic || result.Logic != nil || !result.Solution.Verify() {
+		t.Fatalf("SolveDLX(): expected a unique solution")
+	}
+	board.ForEachRow(func(y, x, val Num) {
+		if val != 0 && result.Solution[y][x] != val {
+			t.Errorf("SolveDLX(): given %d in (%d, %d) changed", val, x+1, y+1)
+		}
+	})
+	if board.CountUnsolved() == 0 {
+		t.Errorf("SolveDLX(): the board was changed")
+	}
+
+	// emptying a rectangle of two numbers in two boxes of the solution
+	// gives two solutions
+	solution := result.Solution
+	var rect []Point
+	for y1 := 0; y1 < Y && rect == nil; y1++ {
+		for y2 := y1/BoxY*BoxY + BoxY; y2 < Y && rect == nil; y2++ {
+			for x1 := 0; x1 < X && rect == nil; x1++ {
+				for x2 := x1 + 1; x2 < x1/BoxX*BoxX+BoxX && rect == nil; x2++ {
+					if solution[y1][x1] == solution[y2][x2] && solution[y1][x2] == solution[y2][x1] {
+						rect = []Point{{x1, y1}, {x2, y1}, {x1, y2}, {x2, y2}}
+					}
+				}
+			}
+		}
+	}
+	if rect == nil {
+		t.Fatalf("no rectangle found in the solution")
+	}
+	board = solution.clone()
+	for _, cell := range rect {
+		board[cell.y][cell.x] = 0
+	}
+	solutions := []Board{}
+	board.ForEachSolution(func(solution Board) bool {
+		solutions = append(solutions, solution)
+		return true
+	})
+	if len(solutions) != 2 || solutions[0].String() == solutions[1].String() {
+		t.Errorf("ForEachSolution(): expected 2 different solutions, got %d", len(solutions))
+	}
+	if n := board.SolveDLX().Solutions; n != 2 {
+		t.Errorf("SolveDLX(): expected 2 solutions, got %d", n)
+	}
+
+	if n := NewBoard().CountSolutions(10); n != 10 {
+		t.Errorf("CountSolutions(): expected 10 solutions for an empty board, got %d", n)
+	}
+}
@@ -40,3 +40,13 @@ func Info(s string, a ...interface{}) {
 	fmt.Printf(s, a...)
 	fmt.Printf("\n")
 }
+
+/*
+ * Runs fn with Debug and Explain output suppressed
+ */
+func quietly(fn func()) {
+	saved := logLevel
+	logLevel = LogNone
+	defer SetLogLevel(saved)
+	fn()
+}
@@ -0,0 +1,229 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import "fmt"
+
+/*
+ * 3D Medusa: coloring like in simple coloring, but over candidates of all
+ * numbers. Candidates are linked both by conjugate pairs in units and by the
+ * two candidates of bivalue cells.
+ */
+
+type Candidate struct {
+	cell Point
+	nr   Num
+}
+
+func (cand Candidate) ToString1() string {
+	return fmt.Sprintf("%d%s", cand.nr, cand.cell.ToString1())
+}
+
+type medusaCluster struct {
+	color map[Candidate]int
+	cands [2][]Candidate
+}
+
+func candidatesString(cands []Candidate) string {
+	str := ""
+	for i, cand := range cands {
+		if i > 0 {
+			str += ", "
+		}
+		str += cand.ToString1()
+	}
+	return str
+}
+
+func findMedusaClusters(game *Game) []medusaCluster {
+	links := map[Candidate][]Candidate{}
+	link := func(a, b Candidate) {
+		links[a] = append(links[a], b)
+		links[b] = append(links[b], a)
+	}
+	for _, possCells := range findUnitPossibleCells(game) {
+		for nr, cells := range possCells {
+			if len(cells) == 2 {
+				link(Candidate{cells[0], Num(nr + 1)}, Candidate{cells[1], Num(nr + 1)})
+			}
+		}
+	}
+	for _, cell := range cellsWithCandidates(game, 2) {
+		cands := game.poss.CellCandidates(cell)
+		link(Candidate{cell, cands[0]}, Candidate{cell, cands[1]})
+	}
+
+	clusters := []medusaCluster{}
+	colored := map[Candidate]bool{}
+	for _, cell := range unsolvedCells(game) {
+		for _, nr := range game.poss.CellCandidates(cell) {
+			start := Candidate{cell, nr}
+			if _, ok := links[start]; !ok || colored[start] {
+				continue
+			}
+			cluster := medusaCluster{color: map[Candidate]int{start: 0}}
+			colored[start] = true
+			queue := []Candidate{start}
+			for len(queue) > 0 {
+				cand := queue[0]
+				queue = queue[1:]
+				color := cluster.color[cand]
+				cluster.cands[color] = append(cluster.cands[color], cand)
+				for _, next := range links[cand] {
+					if !colored[next] {
+						colored[next] = true
+						cluster.color[next] = 1 - color
+						queue = append(queue, next)
+					}
+				}
+			}
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
+}
+
+/*
+ * Returns true if the two candidates of the same number see each other
+ */
+func (cand Candidate) sees(other Candidate) bool {
+	return cand.nr == other.nr && sees(cand.cell, other.cell)
+}
+
+func (cluster *medusaCluster) explain(rule string) {
+	Explain("3D Medusa, %s: color %s %s, color %s %s", rule,
+		colorNames[0], candidatesString(cluster.cands[0]), colorNames[1], candidatesString(cluster.cands[1]))
+}
+
+/*
+ * Returns the color that must be false by rules 1, 2 and 6, or -1
+ */
+func (cluster *medusaCluster) falseColor(game *Game) (int, string) {
+	for color, cands := range cluster.cands {
+		for i, a := range cands {
+			for _, b := range cands[i+1:] {
+				// rule 1: twice in a cell
+				if a.cell == b.cell {
+					return color, "rule 1 (color twice in a cell)"
+				}
+				// rule 2: twice in a unit
+				if a.sees(b) {
+					return color, "rule 2 (color twice in a unit)"
+				}
+			}
+		}
+	}
+
+	// rule 6: a cell without colored candidates where all the candidates
+	// see the same color
+	for _, cell := range unsolvedCells(game) {
+		cands := game.poss.CellCandidates(cell)
+		seen := [2]int{}
+		for _, nr := range cands {
+			cand := Candidate{cell, nr}
+			if _, ok := cluster.color[cand]; ok {
+				seen = [2]int{-1, -1}
+				break
+			}
+			for color := range cluster.cands {
+				for _, other := range cluster.cands[color] {
+					if cand.sees(other) {
+						seen[color]++
+						break
+					}
+				}
+			}
+		}
+		for color := range seen {
+			if len(cands) > 0 && seen[color] == len(cands) {
+				return color, fmt.Sprintf("rule 6 (cell %s emptied by color %s)", cell.ToString1(), colorNames[color])
+			}
+		}
+	}
+	return -1, ""
+}
+
+/*
+ * Scans for 3D Medusa clusters and applies the six rules:
+ * 1. two candidates of the same color in a cell: the color is false
+ * 2. the same number twice in a unit with the same color: the color is false
+ * 3. candidates of both colors in a cell: the other candidates there are false
+ * 4. an uncolored candidate seeing both colors of its number is false
+ * 5. an uncolored candidate seeing a color of its number, in a cell with
+ *    the opposite color, is false
+ * 6. if all the candidates of an uncolored cell see the same color, the
+ *    color is false
+ */
+func (scanner *Scanner) ScanMedusa() int {
+	game := scanner.game
+
+	for _, cluster := range findMedusaClusters(game) {
+		if color, rule := cluster.falseColor(game); color >= 0 {
+			found := 0
+			for _, cand := range cluster.cands[color] {
+				if game.Eliminate(cand.cell, cand.nr) {
+					found++
+				}
+			}
+			if found > 0 {
+				cluster.explain(rule)
+				Explain("Color %s is false", colorNames[color])
+				return found
+			}
+		}
+
+		// candidates to eliminate by rules 3, 4 and 5
+		type elimination struct {
+			cand Candidate
+			rule string
+		}
+		eliminations := []elimination{}
+		for _, cell := range unsolvedCells(game) {
+			// colors of the candidates in this cell
+			cellColors := [2]bool{}
+			for _, nr := range game.poss.CellCandidates(cell) {
+				if color, ok := cluster.color[Candidate{cell, nr}]; ok {
+					cellColors[color] = true
+				}
+			}
+			for _, nr := range game.poss.CellCandidates(cell) {
+				cand := Candidate{cell, nr}
+				if _, ok := cluster.color[cand]; ok {
+					continue
+				}
+				seesColor := [2]bool{}
+				for color := range cluster.cands {
+					for _, other := range cluster.cands[color] {
+						if cand.sees(other) {
+							seesColor[color] = true
+							break
+						}
+					}
+				}
+				switch {
+				case cellColors[0] && cellColors[1]:
+					eliminations = append(eliminations, elimination{cand, "rule 3 (two colors in a cell)"})
+				case seesColor[0] && seesColor[1]:
+					eliminations = append(eliminations, elimination{cand, "rule 4 (two colors elsewhere)"})
+				case cellColors[0] && seesColor[1] || cellColors[1] && seesColor[0]:
+					eliminations = append(eliminations, elimination{cand, "rule 5 (two colors unit + cell)"})
+				}
+			}
+		}
+		if len(eliminations) > 0 {
+			cluster.explain("eliminations")
+			for _, e := range eliminations {
+				Explain("3D Medusa %s: eliminating %d from %s", e.rule, e.cand.nr, e.cand.cell.ToString1())
+				game.Eliminate(e.cand.cell, e.cand.nr)
+			}
+			return len(eliminations)
+		}
+	}
+	return 0
+}
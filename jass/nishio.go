@@ -0,0 +1,50 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Nishio: place a candidate on a trial copy of the game and propagate singles
+ * from it. If that leads to a contradiction, the candidate is eliminated.
+ *
+ * This is trial and error rather than a pattern, so it is rated harder
+ * (DifficultyNishio) than any of the pattern-based techniques and only tried
+ * after all of them. One number is tried at a time, each in all of its
+ * possible places.
+ */
+func (scanner *Scanner) ScanNishio() int {
+	game := scanner.game
+	found := 0
+
+	for nr := Num(1); nr <= NR_MAX && found == 0; nr++ {
+		for _, cell := range unsolvedCells(game) {
+			if !game.poss.Get(Num(cell.y), Num(cell.x), nr) {
+				continue
+			}
+			if trial := game.tryPlace(cell, nr); trial.broken {
+				game.startGuessing()
+				Explain("Nishio (trial and error): placing %d into %s leads to a contradiction, eliminating it",
+					nr, cell.ToString1())
+				game.Eliminate(cell, nr)
+				found++
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Marks in the trace where trial and error starts, once until the next
+ * pattern-based step
+ */
+func (game *Game) startGuessing() {
+	if !game.guessing {
+		Explain("No pattern-based technique applies, resorting to trial and error")
+		game.guessing = true
+	}
+}
@@ -44,6 +44,32 @@ func (set CandidateSet) Add(other CandidateSet) CandidateSet {
 	return newSet
 }
 
+/*
+ * Returns the candidates that are in both sets
+ */
+func (set CandidateSet) Intersect(other CandidateSet) CandidateSet {
+	newSet := CandidateSet{}
+	for _, n := range set {
+		if other.Contains(n) {
+			newSet = append(newSet, n)
+		}
+	}
+	return newSet
+}
+
+/*
+ * Returns the candidates of the set that are not in the other set
+ */
+func (set CandidateSet) Remove(other CandidateSet) CandidateSet {
+	newSet := CandidateSet{}
+	for _, n := range set {
+		if !other.Contains(n) {
+			newSet = append(newSet, n)
+		}
+	}
+	return newSet
+}
+
 func NewPoss() Poss {
 	poss := make(Poss, Y)
 	for i := range poss {
@@ -78,6 +104,13 @@ func (p *Poss) Candidates(y, x Num) CandidateSet {
 	return res
 }
 
+/*
+ * Get candidate numbers for the cell
+ */
+func (p *Poss) CellCandidates(cell Point) CandidateSet {
+	return p.Candidates(Num(cell.y), Num(cell.x))
+}
+
 /*
  * Sets candidate to be possible or not possible for cell (x,y)
  *
@@ -4,14 +4,16 @@
  * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
  *
  * Go version 2019
- *
- * TODO:
- * - X-Wing?
- *
  */
 
 package jass
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type GroupScanFunc func(game *Game, cells []Point) int
 
 func containsInt(slice []int, a int) bool {
@@ -45,6 +47,208 @@ func getBox(y, x int) int {
 	return int(((y/BoxY)*(X/BoxX) + (x / BoxX)))
 }
 
+func rowCells(y int) []Point {
+	cells := make([]Point, 0, X)
+	for x := 0; x < X; x++ {
+		cells = append(cells, Point{y: y, x: x})
+	}
+	return cells
+}
+
+func colCells(x int) []Point {
+	cells := make([]Point, 0, Y)
+	for y := 0; y < Y; y++ {
+		cells = append(cells, Point{y: y, x: x})
+	}
+	return cells
+}
+
+func boxCells(box int) []Point {
+	cells := make([]Point, 0, BoxY*BoxX)
+	y0 := (box / (X / BoxX)) * BoxY
+	x0 := (box % (X / BoxX)) * BoxX
+	for y := y0; y < y0+BoxY; y++ {
+		for x := x0; x < x0+BoxX; x++ {
+			cells = append(cells, Point{y: y, x: x})
+		}
+	}
+	return cells
+}
+
+/*
+ * Units (rows, cols and boxes) are numbered so that rows come first, then
+ * cols and then boxes
+ */
+const (
+	NrUnits  = Y + X + (Y/BoxY)*(X/BoxX)
+	firstCol = Y
+	firstBox = Y + X
+)
+
+const (
+	unitRow = iota
+	unitCol
+	unitBox
+)
+
+var unitKindNames = []string{"row", "col", "box"}
+
+func unitKind(unit int) int {
+	switch {
+	case unit < firstCol:
+		return unitRow
+	case unit < firstBox:
+		return unitCol
+	}
+	return unitBox
+}
+
+/*
+ * Returns the index of the unit among the units of the same kind (0...)
+ */
+func unitIndex(unit int) int {
+	switch unitKind(unit) {
+	case unitRow:
+		return unit
+	case unitCol:
+		return unit - firstCol
+	}
+	return unit - firstBox
+}
+
+func unitCells(unit int) []Point {
+	switch unitKind(unit) {
+	case unitRow:
+		return rowCells(unitIndex(unit))
+	case unitCol:
+		return colCells(unitIndex(unit))
+	}
+	return boxCells(unitIndex(unit))
+}
+
+func unitName(unit int) string {
+	return fmt.Sprintf("%s %d", unitKindNames[unitKind(unit)], unitIndex(unit)+1)
+}
+
+/*
+ * Formats a list of units for humans, e.g. "rows 1, 4 and box 7"
+ */
+func unitsString(units []int) string {
+	parts := []string{}
+	for kind, kindName := range unitKindNames {
+		nums := []string{}
+		for _, unit := range units {
+			if unitKind(unit) == kind {
+				nums = append(nums, strconv.Itoa(unitIndex(unit)+1))
+			}
+		}
+		switch len(nums) {
+		case 0:
+			continue
+		case 1:
+			parts = append(parts, kindName+" "+nums[0])
+		default:
+			parts = append(parts, kindName+"s "+strings.Join(nums, ", "))
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+/*
+ * Returns true if the two different cells are on the same row, col or box
+ */
+func sees(a, b Point) bool {
+	return a != b && (a.x == b.x || a.y == b.y || getBox(a.y, a.x) == getBox(b.y, b.x))
+}
+
+/*
+ * Returns true if the cell sees all the given cells
+ */
+func seesAll(cell Point, cells []Point) bool {
+	for _, other := range cells {
+		if !sees(cell, other) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Returns the unoccupied cells of the board
+ */
+func unsolvedCells(game *Game) []Point {
+	cells := make([]Point, 0, X*Y)
+	game.board.ForEachRow(func(y, x, val Num) {
+		if val == 0 {
+			cells = append(cells, Point{y: int(y), x: int(x)})
+		}
+	})
+	return cells
+}
+
+/*
+ * Eliminates nr from all the cells that see all the given cells
+ */
+func eliminateFromPeers(game *Game, nr Num, cells ...Point) int {
+	found := 0
+	for _, cell := range unsolvedCells(game) {
+		if seesAll(cell, cells) && game.Eliminate(cell, nr) {
+			found++
+		}
+	}
+	return found
+}
+
+/*
+ * A set of cells as a bit mask, for the techniques that need to check a lot
+ * of cells fast
+ */
+type cellMask [2]uint64
+
+func maskOf(cells ...Point) cellMask {
+	var mask cellMask
+	for _, cell := range cells {
+		bit := cell.y*X + cell.x
+		mask[bit/64] |= 1 << uint(bit%64)
+	}
+	return mask
+}
+
+func (mask cellMask) union(other cellMask) cellMask {
+	return cellMask{mask[0] | other[0], mask[1] | other[1]}
+}
+
+func (mask cellMask) intersection(other cellMask) cellMask {
+	return cellMask{mask[0] & other[0], mask[1] & other[1]}
+}
+
+func (mask cellMask) contains(cell Point) bool {
+	return mask.intersects(maskOf(cell))
+}
+
+func (mask cellMask) intersects(other cellMask) bool {
+	return mask[0]&other[0] != 0 || mask[1]&other[1] != 0
+}
+
+func (mask cellMask) subsetOf(other cellMask) bool {
+	return mask[0]&^other[0] == 0 && mask[1]&^other[1] == 0
+}
+
+/*
+ * Returns the cells the cell sees
+ */
+func peerMask(cell Point) cellMask {
+	var mask cellMask
+	for y := 0; y < Y; y++ {
+		for x := 0; x < X; x++ {
+			if other := (Point{x, y}); sees(cell, other) {
+				mask = mask.union(maskOf(other))
+			}
+		}
+	}
+	return mask
+}
+
 type Scanner struct {
 	game *Game
 }
@@ -0,0 +1,178 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Single digit patterns built from conjugate pairs, i.e. units where a number
+ * is possible in exactly two cells (one of which must then be the place for
+ * it).
+ */
+
+/*
+ * Looks for Skyscrapers, Two-String Kites and Empty Rectangles, one number at
+ * a time
+ */
+func (scanner *Scanner) ScanSingleDigitPatterns() int {
+	game := scanner.game
+	found := 0
+	unitPoss := findUnitPossibleCells(game)
+
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		// conjugate pairs on rows and cols
+		pairs := [][]Point{}
+		for unit := 0; unit < firstBox; unit++ {
+			if len(unitPoss[unit][nr-1]) == 2 {
+				pairs = append(pairs, unitPoss[unit][nr-1])
+			}
+		}
+		found += scanSkyscraper(game, nr, pairs)
+		found += scanTwoStringKite(game, nr, pairs)
+		found += scanEmptyRectangle(game, nr, pairs, unitPoss)
+	}
+	return found
+}
+
+func sameRow(a, b Point) bool {
+	return a.y == b.y
+}
+
+func sameCol(a, b Point) bool {
+	return a.x == b.x
+}
+
+/*
+ * Skyscraper: two conjugate pairs on parallel lines, with one end of each on
+ * the same perpendicular line. One of the other two ends must then be the
+ * place, and the number can be eliminated from the cells seeing both.
+ */
+func scanSkyscraper(game *Game, nr Num, pairs [][]Point) int {
+	found := 0
+	for i, pair1 := range pairs {
+		for _, pair2 := range pairs[i+1:] {
+			// both pairs on rows or both on cols
+			var onLine func(a, b Point) bool
+			if sameRow(pair1[0], pair1[1]) && sameRow(pair2[0], pair2[1]) {
+				onLine = sameCol
+			} else if sameCol(pair1[0], pair1[1]) && sameCol(pair2[0], pair2[1]) {
+				onLine = sameRow
+			} else {
+				continue
+			}
+			for end1 := 0; end1 < 2; end1++ {
+				for end2 := 0; end2 < 2; end2++ {
+					base1, roof1 := pair1[end1], pair1[1-end1]
+					base2, roof2 := pair2[end2], pair2[1-end2]
+					if !onLine(base1, base2) || onLine(roof1, roof2) {
+						continue
+					}
+					if n := eliminateFromPeers(game, nr, roof1, roof2); n > 0 {
+						Explain("Skyscraper for %d: base %s and %s, roof %s and %s", nr,
+							base1.ToString1(), base2.ToString1(), roof1.ToString1(), roof2.ToString1())
+						found += n
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Two-String Kite: a conjugate pair on a row and another on a col, with one
+ * end of each in the same box. One of the other two ends must be the place,
+ * and the number can be eliminated from the cells seeing both.
+ */
+func scanTwoStringKite(game *Game, nr Num, pairs [][]Point) int {
+	found := 0
+	for _, row := range pairs {
+		if !sameRow(row[0], row[1]) {
+			continue
+		}
+		for _, col := range pairs {
+			if !sameCol(col[0], col[1]) {
+				continue
+			}
+			for end1 := 0; end1 < 2; end1++ {
+				for end2 := 0; end2 < 2; end2++ {
+					a, rowEnd := row[end1], row[1-end1]
+					b, colEnd := col[end2], col[1-end2]
+					if a == b || rowEnd == colEnd || a == colEnd || b == rowEnd ||
+						getBox(a.y, a.x) != getBox(b.y, b.x) ||
+						getBox(rowEnd.y, rowEnd.x) == getBox(a.y, a.x) ||
+						getBox(colEnd.y, colEnd.x) == getBox(b.y, b.x) {
+						continue
+					}
+					if n := eliminateFromPeers(game, nr, rowEnd, colEnd); n > 0 {
+						Explain("Two-String Kite for %d: row %d %s-%s and col %d %s-%s connected in box %d", nr,
+							a.y+1, a.ToString1(), rowEnd.ToString1(), b.x+1, b.ToString1(), colEnd.ToString1(),
+							getBox(a.y, a.x)+1)
+						found += n
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Empty Rectangle: the possible cells of a number in a box are all on one row
+ * and one col of the box. If there is a conjugate pair on a line crossing the
+ * row (col) of the box outside it, either the far end of the pair or the col
+ * (row) of the box gets the number, so it can be eliminated from the cell
+ * where these two meet.
+ */
+func scanEmptyRectangle(game *Game, nr Num, pairs [][]Point, unitPoss [][][]Point) int {
+	found := 0
+	for box := 0; box < NrUnits-firstBox; box++ {
+		boxPoss := unitPoss[firstBox+box][nr-1]
+		if len(boxPoss) < 2 {
+			continue
+		}
+		cells := boxCells(box)
+		// the row and col of the box all the possible cells are on
+		for _, center := range cells {
+			onCross := true
+			for _, cell := range boxPoss {
+				if !sameRow(cell, center) && !sameCol(cell, center) {
+					onCross = false
+					break
+				}
+			}
+			if !onCross {
+				continue
+			}
+			for _, pair := range pairs {
+				for end := 0; end < 2; end++ {
+					near, far := pair[end], pair[1-end]
+					var target Point
+					if sameCol(near, far) && sameRow(near, center) {
+						// pair on a col crossing the row of the box
+						target = Point{y: far.y, x: center.x}
+					} else if sameRow(near, far) && sameCol(near, center) {
+						// pair on a row crossing the col of the box
+						target = Point{y: center.y, x: far.x}
+					} else {
+						continue
+					}
+					if getBox(near.y, near.x) == box || getBox(far.y, far.x) == box ||
+						getBox(target.y, target.x) == box || target == far {
+						continue
+					}
+					if game.Eliminate(target, nr) {
+						Explain("Empty Rectangle for %d in box %d (row %d, col %d), conjugate pair %s-%s, eliminating %d from %s",
+							nr, box+1, center.y+1, center.x+1, near.ToString1(), far.ToString1(), nr, target.ToString1())
+						found++
+					}
+				}
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,150 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Sue de Coq: two or three unoccupied cells in the intersection of a box and
+ * a line with candidates V, at least two more than there are cells. Some
+ * other cells in the line have candidates VL and some other cells in the box
+ * VB, both from V but with nothing in common, and there are as many cells
+ * altogether as there are numbers in V. Each number of V must then be placed
+ * exactly once in the cells: VL in the intersection or the line cells, VB in
+ * the intersection or the box cells, and the rest in the intersection. So VL
+ * can be eliminated from the rest of the line, VB from the rest of the box
+ * and the rest of V from both.
+ */
+func (scanner *Scanner) ScanSueDeCoq() int {
+	game := scanner.game
+	found := 0
+
+	for box := 0; box < (Y/BoxY)*(X/BoxX); box++ {
+		cells := boxCells(box)
+		y0, x0 := cells[0].y, cells[0].x
+		lines := []int{}
+		for y := y0; y < y0+BoxY; y++ {
+			lines = append(lines, y)
+		}
+		for x := x0; x < x0+BoxX; x++ {
+			lines = append(lines, firstCol+x)
+		}
+		for _, line := range lines {
+			found += scanSueDeCoq(game, firstBox+box, line)
+		}
+	}
+	return found
+}
+
+func scanSueDeCoq(game *Game, box, line int) int {
+	found := 0
+	inBox := PointSet(unitCells(box))
+	inLine := PointSet(unitCells(line))
+
+	intersection := PointSet{}
+	lineRest := PointSet{}
+	boxRest := PointSet{}
+	for _, cell := range unsolvedCells(game) {
+		switch {
+		case inBox.Contains(cell) && inLine.Contains(cell):
+			intersection = append(intersection, cell)
+		case inLine.Contains(cell):
+			lineRest = append(lineRest, cell)
+		case inBox.Contains(cell):
+			boxRest = append(boxRest, cell)
+		}
+	}
+
+	for size := 2; size <= len(intersection); size++ {
+		comb(len(intersection), size, func(c []int) {
+			cells := PointSet{}
+			v := CandidateSet{}
+			for _, i := range c {
+				cells = append(cells, intersection[i])
+				v = v.Add(game.poss.CellCandidates(intersection[i]))
+			}
+			if len(v) < size+2 {
+				return
+			}
+			for _, lineCells := range subsetsWithin(game, lineRest, v) {
+				vl := candidatesOf(game, lineCells)
+				for _, boxCells := range subsetsWithin(game, boxRest, v.Remove(vl)) {
+					if size+len(lineCells)+len(boxCells) != len(v) {
+						continue
+					}
+					vb := candidatesOf(game, boxCells)
+					all := append(append(PointSet{}, cells...), lineCells...)
+					all = append(all, boxCells...)
+					rest := v.Remove(vl).Remove(vb)
+
+					eliminated := 0
+					for _, cell := range unsolvedCells(game) {
+						if all.Contains(cell) {
+							continue
+						}
+						var nrs CandidateSet
+						switch {
+						case inLine.Contains(cell) && inBox.Contains(cell):
+							nrs = v
+						case inLine.Contains(cell):
+							nrs = vl.Add(rest)
+						case inBox.Contains(cell):
+							nrs = vb.Add(rest)
+						}
+						for _, nr := range nrs {
+							if game.Eliminate(cell, nr) {
+								eliminated++
+							}
+						}
+					}
+					if eliminated > 0 {
+						Explain("Sue de Coq: %s %v in %s and %s, %s %v in the %s, %s %v in the box",
+							cells.ToString1(), v, unitName(line), unitName(box), lineCells.ToString1(), vl,
+							unitKindNames[unitKind(line)], boxCells.ToString1(), vb)
+						found += eliminated
+					}
+				}
+			}
+		})
+	}
+	return found
+}
+
+/*
+ * Returns all the non-empty subsets of the cells that have only candidates
+ * from v
+ */
+func subsetsWithin(game *Game, cells PointSet, v CandidateSet) []PointSet {
+	within := PointSet{}
+	for _, cell := range cells {
+		if len(game.poss.CellCandidates(cell).Remove(v)) == 0 {
+			within = append(within, cell)
+		}
+	}
+	subsets := []PointSet{}
+	for size := 1; size <= len(within); size++ {
+		comb(len(within), size, func(c []int) {
+			subset := PointSet{}
+			for _, i := range c {
+				subset = append(subset, within[i])
+			}
+			subsets = append(subsets, subset)
+		})
+	}
+	return subsets
+}
+
+/*
+ * Returns all the candidates of the cells
+ */
+func candidatesOf(game *Game, cells PointSet) CandidateSet {
+	cands := CandidateSet{}
+	for _, cell := range cells {
+		cands = cands.Add(game.poss.CellCandidates(cell))
+	}
+	return cands
+}
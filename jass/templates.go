@@ -0,0 +1,164 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Pattern Overlay Method: a template of a number is a placement of all its
+ * nine instances, one in each row, col and box, in cells where it is placed
+ * or possible. A candidate in no template of its number can be eliminated,
+ * and a number in the same cell in all its templates can be placed.
+ *
+ * The templates of different numbers can't overlap, so a template that
+ * overlaps all the templates of some other number is dropped too. Comparing
+ * the templates one by one is slow with lots of them, so it is only done if
+ * no number has more than maxCrossTemplates of them. Otherwise a template is
+ * only checked against the cells that are in all the templates of the other
+ * numbers.
+ */
+const maxCrossTemplates = 50
+
+func (scanner *Scanner) ScanTemplates() int {
+	game := scanner.game
+	templates := make([][]cellMask, NR_MAX)
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		templates[nr-1] = findTemplates(game, nr)
+		if len(templates[nr-1]) == 0 {
+			// the puzzle is broken
+			return 0
+		}
+	}
+	crossCheckTemplates(templates)
+
+	found := 0
+	for nr := Num(1); nr <= NR_MAX; nr++ {
+		if len(templates[nr-1]) == 0 {
+			return found
+		}
+		all := intersectionOf(templates[nr-1])
+		some := templates[nr-1][0]
+		for _, template := range templates[nr-1][1:] {
+			some = some.union(template)
+		}
+		for _, cell := range unsolvedCells(game) {
+			if !game.poss.Get(Num(cell.y), Num(cell.x), nr) {
+				continue
+			}
+			if all.contains(cell) {
+				Explain("Templates: %d is in %s in all of its %d templates", nr, cell.ToString1(), len(templates[nr-1]))
+				game.Fix(Num(cell.y), Num(cell.x), nr)
+				found++
+			} else if !some.contains(cell) {
+				Explain("Templates: %d is in %s in none of its %d templates", nr, cell.ToString1(), len(templates[nr-1]))
+				game.Eliminate(cell, nr)
+				found++
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Finds all the templates of the number, row by row
+ */
+func findTemplates(game *Game, nr Num) []cellMask {
+	templates := []cellMask{}
+	var find func(y int, cols, boxes int, template cellMask)
+	find = func(y int, cols, boxes int, template cellMask) {
+		if y == Y {
+			templates = append(templates, template)
+			return
+		}
+		for x := 0; x < X; x++ {
+			box := getBox(y, x)
+			if cols&(1<<uint(x)) != 0 || boxes&(1<<uint(box)) != 0 {
+				continue
+			}
+			if val := game.board[y][x]; val != nr && (val != 0 || !game.poss.Get(Num(y), Num(x), nr)) {
+				continue
+			}
+			find(y+1, cols|1<<uint(x), boxes|1<<uint(box), template.union(maskOf(Point{x, y})))
+		}
+	}
+	find(0, 0, 0, cellMask{})
+	return templates
+}
+
+/*
+ * Returns the cells that are in all the templates
+ */
+func intersectionOf(templates []cellMask) cellMask {
+	if len(templates) == 0 {
+		return cellMask{}
+	}
+	all := templates[0]
+	for _, template := range templates[1:] {
+		all = all.intersection(template)
+	}
+	return all
+}
+
+/*
+ * Drops the templates that overlap all the templates of some other number,
+ * until there is nothing more to drop
+ */
+func crossCheckTemplates(templates [][]cellMask) {
+	for changed := true; changed; {
+		changed = false
+		// each template is compared with all the others only if there are
+		// few enough of them
+		oneByOne := true
+		all := make([]cellMask, len(templates))
+		for i := range templates {
+			all[i] = intersectionOf(templates[i])
+			oneByOne = oneByOne && len(templates[i]) <= maxCrossTemplates
+		}
+		for i := range templates {
+			kept := []cellMask{}
+			for _, template := range templates[i] {
+				if fitsWithOthers(template, i, templates, all, oneByOne) {
+					kept = append(kept, template)
+				}
+			}
+			if len(kept) != len(templates[i]) {
+				templates[i] = kept
+				changed = true
+			}
+		}
+	}
+}
+
+/*
+ * Returns true if the template of number i (index) leaves room for some
+ * template of each other number, given the cells in all the templates of
+ * each number
+ */
+func fitsWithOthers(template cellMask, i int, templates [][]cellMask, all []cellMask, oneByOne bool) bool {
+	for j := range templates {
+		if j == i {
+			continue
+		}
+		if template.intersects(all[j]) {
+			return false
+		}
+		if !oneByOne {
+			continue
+		}
+		fits := false
+		for _, other := range templates[j] {
+			if !template.intersects(other) {
+				fits = true
+				break
+			}
+		}
+		if !fits {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+import "sync"
+
+/*
+ * Tridagon (Thor's Hammer): four boxes in the corners of a rectangle, with
+ * three unoccupied cells in each box, one on each row and col of the box.
+ * For some shapes, the cells can't get only three numbers {a, b, c}: each box
+ * would need all three, but the rows and cols shared by the boxes don't let
+ * them fit. So at least one of the cells must get some other number, a
+ * guardian candidate. If there is only one cell with guardians, a, b and c
+ * can be eliminated from it, and if all the guardians are the same number,
+ * it can be eliminated from all the cells seeing all of them.
+ */
+
+var perms3 = [6][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+
+/*
+ * The shapes of the cells that can't get only three numbers. Boxes 0 and 1
+ * of the shape are on one band and 2 and 3 on the other, boxes 0 and 2 on one
+ * stack and 1 and 3 on the other. The cell on row k of box j is on col
+ * perms3[shape[j]][k] of the box. Found once, when first needed.
+ */
+var (
+	tridagonShapes     [][4]int
+	tridagonShapesOnce sync.Once
+)
+
+func findTridagonShapes() [][4]int {
+	shapes := [][4]int{}
+	for s := 0; s < 6*6*6*6; s++ {
+		shape := [4]int{s % 6, s / 6 % 6, s / 36 % 6, s / 216}
+		if !tridagonFits(shape) {
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes
+}
+
+/*
+ * Returns true if three numbers can be placed in the cells of the shape
+ */
+func tridagonFits(shape [4]int) bool {
+	for v := 0; v < 6*6*6*6; v++ {
+		// the number of the cell on row k of box j is perms3[vals[j]][k]
+		vals := [4]int{v % 6, v / 6 % 6, v / 36 % 6, v / 216}
+		fits := true
+		for k := 0; k < 3; k++ {
+			// rows
+			fits = fits && perms3[vals[0]][k] != perms3[vals[1]][k] && perms3[vals[2]][k] != perms3[vals[3]][k]
+			// cols
+			for _, pair := range [][2]int{{0, 2}, {1, 3}} {
+				a, b := pair[0], pair[1]
+				for m := 0; m < 3; m++ {
+					if perms3[shape[a]][k] == perms3[shape[b]][m] && perms3[vals[a]][k] == perms3[vals[b]][m] {
+						fits = false
+					}
+				}
+			}
+		}
+		if fits {
+			return true
+		}
+	}
+	return false
+}
+
+func (scanner *Scanner) ScanTridagon() int {
+	game := scanner.game
+	found := 0
+	tridagonShapesOnce.Do(func() {
+		tridagonShapes = findTridagonShapes()
+	})
+
+	for band1 := 0; band1 < Y/BoxY; band1++ {
+		for band2 := band1 + 1; band2 < Y/BoxY; band2++ {
+			for stack1 := 0; stack1 < X/BoxX; stack1++ {
+				for stack2 := stack1 + 1; stack2 < X/BoxX; stack2++ {
+					corners := [4][2]int{{band1, stack1}, {band1, stack2}, {band2, stack1}, {band2, stack2}}
+					for _, shape := range tridagonShapes {
+						cells := PointSet{}
+						for j, corner := range corners {
+							for k := 0; k < 3; k++ {
+								cells = append(cells, Point{
+									x: corner[1]*BoxX + perms3[shape[j]][k],
+									y: corner[0]*BoxY + k,
+								})
+							}
+						}
+						found += scanTridagonCells(game, cells)
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+func scanTridagonCells(game *Game, cells PointSet) int {
+	cands := make([]CandidateSet, len(cells))
+	all := CandidateSet{}
+	for i, cell := range cells {
+		if game.board.CellOccupied(cell) {
+			return 0
+		}
+		cands[i] = game.poss.CellCandidates(cell)
+		all = all.Add(cands[i])
+	}
+
+	found := 0
+	comb(len(all), 3, func(c []int) {
+		if found > 0 {
+			return
+		}
+		abc := CandidateSet{all[c[0]], all[c[1]], all[c[2]]}
+		guardianCells := PointSet{}
+		guardians := CandidateSet{}
+		for i, cell := range cells {
+			if len(cands[i].Intersect(abc)) < 2 {
+				// not a tridagon for these numbers
+				return
+			}
+			if extra := cands[i].Remove(abc); len(extra) > 0 {
+				guardianCells = append(guardianCells, cell)
+				guardians = guardians.Add(extra)
+			}
+		}
+
+		switch {
+		case len(guardianCells) == 1:
+			cell := guardianCells[0]
+			for _, nr := range abc {
+				if game.Eliminate(cell, nr) {
+					found++
+				}
+			}
+			if found > 0 {
+				Explain("Tridagon for %v: %s, the only guardian cell %s must be %v", abc, cells.ToString1(),
+					cell.ToString1(), guardians)
+			}
+		case len(guardians) == 1:
+			if n := eliminateFromPeers(game, guardians[0], guardianCells...); n > 0 {
+				Explain("Tridagon for %v: %s, guardian %d in %s, eliminating it from the cells seeing them all", abc,
+					cells.ToString1(), guardians[0], guardianCells.ToString1())
+				found += n
+			}
+		}
+	})
+	return found
+}
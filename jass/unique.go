@@ -0,0 +1,390 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Uniqueness techniques assume that the puzzle has only one solution. Four
+ * unoccupied cells in the corners of a rectangle spanning two boxes can't
+ * all end up with the same two numbers {a, b}: the numbers could then be
+ * swapped to get another solution (a deadly pattern). They are only used
+ * if enabled with SetUniqueness.
+ *
+ * In a Unique Rectangle, the floor cells have only candidates {a, b} and the
+ * roof cells have some extra candidates as well. The types are:
+ * 1: one roof cell, a and b can be eliminated from it
+ * 2: two roof cells in the same unit with the same one extra candidate c, c
+ *    can be eliminated from all the cells seeing both
+ * 3: two roof cells in the same unit, their extra candidates form a naked
+ *    subset with other cells in the unit
+ * 4: two roof cells in the same unit, a is possible only in them in the
+ *    unit, so b can be eliminated from them
+ * 5: like type 2, but with diagonal or three roof cells
+ * 6: diagonal floor cells, and a is possible only in the rectangle on both
+ *    rows or both cols, so a can be eliminated from the roof cells
+ * Hidden: in the row and col of the roof cell opposite to a floor cell, a is
+ *    possible only in the rectangle, so b can be eliminated from it
+ */
+
+/*
+ * The corners of the rectangle are numbered so that the corner on the same
+ * row as corner i is i^1, the corner on the same col i^2 and the opposite
+ * corner 3-i
+ */
+type uniqueRect struct {
+	cells [4]Point
+	a, b  Num
+}
+
+func (rect uniqueRect) ToString1() string {
+	return PointSet(rect.cells[:]).ToString1()
+}
+
+/*
+ * Returns the rows, cols and boxes of the cell
+ */
+func cellUnits(cell Point) []int {
+	return []int{cell.y, firstCol + cell.x, firstBox + getBox(cell.y, cell.x)}
+}
+
+/*
+ * Returns the units shared by the two cells
+ */
+func sharedUnits(a, b Point) []int {
+	shared := []int{}
+	for _, unit := range cellUnits(a) {
+		if containsInt(cellUnits(b), unit) {
+			shared = append(shared, unit)
+		}
+	}
+	return shared
+}
+
+/*
+ * Returns true if nr is possible in the unit only in the given cells
+ */
+func onlyIn(unitPoss [][][]Point, unit int, nr Num, cells ...Point) bool {
+	possCells := unitPoss[unit][nr-1]
+	if len(possCells) != len(cells) {
+		return false
+	}
+	for _, cell := range cells {
+		if !PointSet(possCells).Contains(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Finds the rectangles of unoccupied cells spanning two boxes that all have
+ * both a and b as candidates, and at least one cell with only them
+ */
+func findUniqueRects(game *Game) []uniqueRect {
+	rects := []uniqueRect{}
+
+	for y1 := 0; y1 < Y; y1++ {
+		for y2 := y1 + 1; y2 < Y; y2++ {
+			for x1 := 0; x1 < X; x1++ {
+				for x2 := x1 + 1; x2 < X; x2++ {
+					if (y1/BoxY == y2/BoxY) == (x1/BoxX == x2/BoxX) {
+						// one or four boxes
+						continue
+					}
+					rect := uniqueRect{cells: [4]Point{{x1, y1}, {x2, y1}, {x1, y2}, {x2, y2}}}
+					common := CandidateSet{1, 2, 3, 4, 5, 6, 7, 8, 9}
+					bivalue := false
+					for _, cell := range rect.cells {
+						if game.board[cell.y][cell.x] != 0 {
+							common = nil
+							break
+						}
+						cands := game.poss.CellCandidates(cell)
+						common = common.Intersect(cands)
+						bivalue = bivalue || len(cands) == 2
+					}
+					if len(common) < 2 || !bivalue {
+						continue
+					}
+					comb(len(common), 2, func(c []int) {
+						rect.a, rect.b = common[c[0]], common[c[1]]
+						rects = append(rects, rect)
+					})
+				}
+			}
+		}
+	}
+	return rects
+}
+
+/*
+ * Scans for Unique Rectangles of all types, if uniqueness is assumed
+ */
+func (scanner *Scanner) ScanUniqueRectangles() int {
+	game := scanner.game
+	if !game.uniqueness {
+		return 0
+	}
+	found := 0
+	for _, rect := range findUniqueRects(game) {
+		found += rect.scan(game)
+	}
+	return found
+}
+
+func (rect uniqueRect) scan(game *Game) int {
+	ab := CandidateSet{rect.a, rect.b}
+	cands := [4]CandidateSet{}
+	floor := []int{}
+	roof := []int{}
+	for i, cell := range rect.cells {
+		cands[i] = game.poss.CellCandidates(cell)
+		if len(cands[i].Intersect(ab)) != 2 {
+			// changed by an earlier elimination
+			return 0
+		}
+		if len(cands[i]) == 2 {
+			floor = append(floor, i)
+		} else {
+			roof = append(roof, i)
+		}
+	}
+	roofCells := PointSet{}
+	for _, i := range roof {
+		roofCells = append(roofCells, rect.cells[i])
+	}
+	if len(roof) == 0 {
+		// a deadly pattern already
+		return 0
+	}
+	unitPoss := findUnitPossibleCells(game)
+	found := 0
+
+	switch len(roof) {
+	case 1:
+		cell := rect.cells[roof[0]]
+		for _, nr := range ab {
+			if game.Eliminate(cell, nr) {
+				found++
+			}
+		}
+		if found > 0 {
+			Explain("Unique Rectangle type 1: %s %v, eliminating %v from %s", rect.ToString1(), ab, ab, cell.ToString1())
+		}
+		return found
+	case 2:
+		if roof[0] == 3-roof[1] {
+			found += rect.scanDiagonal(game, unitPoss, floor, roof)
+		} else {
+			found += rect.scanSameUnit(game, unitPoss, roofCells, cands[roof[0]].Add(cands[roof[1]]).Remove(ab))
+		}
+	}
+	// types 2 and 5: the roof cells have the same one extra candidate
+	extra := cands[roof[0]].Remove(ab)
+	sameExtra := len(extra) == 1
+	for _, i := range roof {
+		sameExtra = sameExtra && cands[i].Equals(cands[roof[0]])
+	}
+	if sameExtra {
+		if n := eliminateFromPeers(game, extra[0], roofCells...); n > 0 {
+			kind := 5
+			if len(roof) == 2 && len(sharedUnits(roofCells[0], roofCells[1])) > 0 {
+				kind = 2
+			}
+			Explain("Unique Rectangle type %d: %s %v, one of %s must be %d, eliminating it",
+				kind, rect.ToString1(), ab, roofCells.ToString1(), extra[0])
+			found += n
+		}
+	}
+	found += rect.scanHidden(game, unitPoss, floor)
+	return found
+}
+
+/*
+ * Types 3 and 4, with the roof cells in the same unit
+ */
+func (rect uniqueRect) scanSameUnit(game *Game, unitPoss [][][]Point, roofCells PointSet, extra CandidateSet) int {
+	ab := CandidateSet{rect.a, rect.b}
+	found := 0
+
+	for _, unit := range sharedUnits(roofCells[0], roofCells[1]) {
+		// type 3: the extra candidates act as one cell that forms a naked
+		// subset with other cells in the unit
+		others := PointSet{}
+		for _, cell := range unitCells(unit) {
+			if game.board[cell.y][cell.x] == 0 && !roofCells.Contains(cell) {
+				others = append(others, cell)
+			}
+		}
+		done := false
+		for size := 1; size < len(others) && !done; size++ {
+			comb(len(others), size, func(c []int) {
+				if done {
+					return
+				}
+				subset := CandidateSet{}.Add(extra)
+				subsetCells := PointSet{}
+				for _, i := range c {
+					subset = subset.Add(game.poss.CellCandidates(others[i]))
+					subsetCells = append(subsetCells, others[i])
+				}
+				if len(subset) != size+1 {
+					return
+				}
+				eliminated := 0
+				for _, cell := range others {
+					if subsetCells.Contains(cell) {
+						continue
+					}
+					for _, nr := range subset {
+						if game.Eliminate(cell, nr) {
+							eliminated++
+						}
+					}
+				}
+				if eliminated > 0 {
+					Explain("Unique Rectangle type 3: %s %v, extra candidates %v form a naked subset %v with %s in %s",
+						rect.ToString1(), ab, extra, subset, subsetCells.ToString1(), unitName(unit))
+					found += eliminated
+					done = true
+				}
+			})
+		}
+
+		// type 4: a is possible only in the roof cells in the unit
+		for j, nr := range ab {
+			if !onlyIn(unitPoss, unit, nr, roofCells...) {
+				continue
+			}
+			other := ab[1-j]
+			eliminated := 0
+			for _, cell := range roofCells {
+				if game.Eliminate(cell, other) {
+					eliminated++
+				}
+			}
+			if eliminated > 0 {
+				Explain("Unique Rectangle type 4: %s %v, %d is possible only in %s in %s, eliminating %d from them",
+					rect.ToString1(), ab, nr, roofCells.ToString1(), unitName(unit), other)
+				found += eliminated
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * Type 6, with diagonal floor and roof cells
+ */
+func (rect uniqueRect) scanDiagonal(game *Game, unitPoss [][][]Point, floor, roof []int) int {
+	ab := CandidateSet{rect.a, rect.b}
+	c := rect.cells
+	found := 0
+
+	for _, nr := range ab {
+		rows := onlyIn(unitPoss, c[0].y, nr, c[0], c[1]) && onlyIn(unitPoss, c[2].y, nr, c[2], c[3])
+		cols := onlyIn(unitPoss, firstCol+c[0].x, nr, c[0], c[2]) && onlyIn(unitPoss, firstCol+c[1].x, nr, c[1], c[3])
+		if !rows && !cols {
+			continue
+		}
+		eliminated := 0
+		for _, i := range roof {
+			if game.Eliminate(c[i], nr) {
+				eliminated++
+			}
+		}
+		if eliminated > 0 {
+			Explain("Unique Rectangle type 6: %s %v, %d is possible only in the rectangle on its rows or cols, eliminating it from %s and %s",
+				rect.ToString1(), ab, nr, c[roof[0]].ToString1(), c[roof[1]].ToString1())
+			found += eliminated
+		}
+	}
+	return found
+}
+
+/*
+ * Hidden Unique Rectangle: if b was in the corner opposite to a floor cell, a
+ * would have to be in the two other roof corners by the strong links, and the
+ * floor cell would have to be b
+ */
+func (rect uniqueRect) scanHidden(game *Game, unitPoss [][][]Point, floor []int) int {
+	ab := CandidateSet{rect.a, rect.b}
+	c := rect.cells
+	found := 0
+
+	for _, f := range floor {
+		o := 3 - f
+		if len(game.poss.CellCandidates(c[o])) == 2 {
+			continue
+		}
+		for j, nr := range ab {
+			if !onlyIn(unitPoss, c[o].y, nr, c[o], c[o^1]) || !onlyIn(unitPoss, firstCol+c[o].x, nr, c[o], c[o^2]) {
+				continue
+			}
+			other := ab[1-j]
+			if game.Eliminate(c[o], other) {
+				Explain("Hidden Unique Rectangle: %s %v, %d is possible only in the rectangle on the row and col of %s, eliminating %d from it",
+					rect.ToString1(), ab, nr, c[o].ToString1(), other)
+				found++
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * BUG+1 (Bivalue Universal Grave): if all the unoccupied cells but one have
+ * two candidates, and the remaining cell has three, the puzzle would have
+ * two solutions without the candidate of that cell that is possible three
+ * times in its row, col and box. So that candidate must be placed.
+ */
+func (scanner *Scanner) ScanBUG() int {
+	game := scanner.game
+	if !game.uniqueness {
+		return 0
+	}
+	var extra Point
+	trivalues := 0
+	for _, cell := range unsolvedCells(game) {
+		switch len(game.poss.CellCandidates(cell)) {
+		case 2:
+		case 3:
+			extra = cell
+			trivalues++
+		default:
+			return 0
+		}
+	}
+	if trivalues != 1 {
+		return 0
+	}
+
+	unitPoss := findUnitPossibleCells(game)
+	for _, nr := range game.poss.CellCandidates(extra) {
+		// without the extra candidate, each candidate must be possible
+		// exactly twice in each unit
+		bug := true
+		for unit := range unitPoss {
+			for k, possCells := range unitPoss[unit] {
+				n := len(possCells)
+				if Num(k+1) == nr && PointSet(possCells).Contains(extra) {
+					n--
+				}
+				if n != 0 && n != 2 {
+					bug = false
+				}
+			}
+		}
+		if bug {
+			Explain("BUG+1: all cells but %s have two candidates, placing %d into it", extra.ToString1(), nr)
+			game.Fix(Num(extra.y), Num(extra.x), nr)
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+/* vim: set sts=4 sw=4 ts=4 noet: */
+/**
+ * jass - just another sudoku solver
+ * (C) 2005-2019 Jari Tenhunen <jait@iki.fi>
+ *
+ * Go version 2019
+ */
+
+package jass
+
+/*
+ * Returns the unoccupied cells with the given number of candidates
+ */
+func cellsWithCandidates(game *Game, n int) []Point {
+	cells := []Point{}
+	for _, cell := range unsolvedCells(game) {
+		if len(game.poss.CellCandidates(cell)) == n {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+/*
+ * XY-Wing: a pivot cell with candidates {x, y} sees two pincer cells with
+ * candidates {x, z} and {y, z}. Whichever the pivot gets, one of the pincers
+ * will be z, so z can be eliminated from all the cells seeing both pincers.
+ */
+func (scanner *Scanner) ScanXYWing() int {
+	return scanWings(scanner.game, 2)
+}
+
+/*
+ * XYZ-Wing: like XY-Wing, but the pivot has candidates {x, y, z}, so z can
+ * only be eliminated from the cells seeing the pivot as well as both pincers.
+ */
+func (scanner *Scanner) ScanXYZWing() int {
+	return scanWings(scanner.game, 3)
+}
+
+func scanWings(game *Game, pivotLen int) int {
+	found := 0
+	name := "XY-Wing"
+	if pivotLen == 3 {
+		name = "XYZ-Wing"
+	}
+	bivalues := cellsWithCandidates(game, 2)
+
+	for _, pivot := range cellsWithCandidates(game, pivotLen) {
+		pivotCands := game.poss.CellCandidates(pivot)
+		if len(pivotCands) != pivotLen {
+			// changed by an earlier elimination
+			continue
+		}
+		// bivalue cells seeing the pivot and sharing one candidate with it
+		// (XY) or having both candidates in it (XYZ)
+		pincers := []Point{}
+		for _, cell := range bivalues {
+			common := len(game.poss.CellCandidates(cell).Intersect(pivotCands))
+			if sees(cell, pivot) && common == pivotLen-1 {
+				pincers = append(pincers, cell)
+			}
+		}
+
+		for i, a := range pincers {
+			aCands := game.poss.CellCandidates(a)
+			for _, b := range pincers[i+1:] {
+				bCands := game.poss.CellCandidates(b)
+				// the pincers share exactly one candidate z, and together
+				// with the pivot they have three candidates
+				common := aCands.Intersect(bCands)
+				if len(common) != 1 || len(aCands.Add(bCands).Add(pivotCands)) != 3 {
+					continue
+				}
+				z := common[0]
+				if pivotLen == 2 && pivotCands.Contains(z) {
+					continue
+				}
+				targets := []Point{a, b}
+				if pivotLen == 3 {
+					targets = append(targets, pivot)
+				}
+				if n := eliminateFromPeers(game, z, targets...); n > 0 {
+					Explain("%s: pivot %s %v, pincers %s %v and %s %v, eliminating %d", name,
+						pivot.ToString1(), pivotCands, a.ToString1(), aCands, b.ToString1(), bCands, z)
+					found += n
+				}
+			}
+		}
+	}
+	return found
+}
+
+/*
+ * W-Wing: two cells with the same two candidates {x, y} that are connected
+ * with a strong link on x, i.e. a unit where x is possible only in two cells,
+ * one seeing each of the pair. One of the pair must be y, so y can be
+ * eliminated from all the cells seeing both.
+ */
+func (scanner *Scanner) ScanWWing() int {
+	game := scanner.game
+	found := 0
+	bivalues := cellsWithCandidates(game, 2)
+	unitPoss := findUnitPossibleCells(game)
+
+	for i, a := range bivalues {
+		cands := game.poss.CellCandidates(a)
+		if len(cands) != 2 {
+			// changed by an earlier elimination
+			continue
+		}
+		for _, b := range bivalues[i+1:] {
+			if sees(a, b) || !game.poss.CellCandidates(b).Equals(cands) {
+				continue
+			}
+			for j, x := range cands {
+				y := cands[1-j]
+				for unit := range unitPoss {
+					link := unitPoss[unit][x-1]
+					if len(link) != 2 {
+						continue
+					}
+					for end := 0; end < 2; end++ {
+						p, q := link[end], link[1-end]
+						if p == a || p == b || q == a || q == b || !sees(a, p) || !sees(b, q) {
+							continue
+						}
+						if n := eliminateFromPeers(game, y, a, b); n > 0 {
+							Explain("W-Wing: %s and %s %v linked by strong link on %d in %s between %s and %s, eliminating %d",
+								a.ToString1(), b.ToString1(), cands, x, unitName(unit), p.ToString1(), q.ToString1(), y)
+							found += n
+						}
+					}
+				}
+			}
+		}
+	}
+	return found
+}
@@ -25,11 +25,22 @@ func main() {
 	 * -f: read sudokus from file (- for stdin)
 	 */
 
-	var fname string
-	var step, verbose bool
+	var fname, engine string
+	var step, verbose, mutant, alsNodes, unique, exocet, backtrack bool
+	var fishSize, chainLength, forcingDepth int
 
 	flag.BoolVar(&step, "s", false, "step mode, pause after each solved number")
 	flag.BoolVar(&verbose, "v", false, "verbose debug output")
+	flag.IntVar(&fishSize, "fish", jass.DefaultFishSize,
+		fmt.Sprintf("maximum `size` of Franken and mutant fish, %d...%d", jass.MinFishSize, jass.MaxFishSize))
+	flag.BoolVar(&mutant, "mutant", false, "look for mutant fish")
+	flag.IntVar(&chainLength, "chain", jass.DefaultChainLength, "maximum `length` of chains and loops")
+	flag.BoolVar(&alsNodes, "als", false, "use almost locked sets as nodes of alternating inference chains")
+	flag.IntVar(&forcingDepth, "forcing", jass.DefaultForcingDepth, "maximum `depth` (rounds of singles) of forcing chains")
+	flag.BoolVar(&unique, "unique", false, "assume the puzzle has only one solution, enables uniqueness techniques")
+	flag.BoolVar(&exocet, "exocet", false, "look for Junior Exocets")
+	flag.BoolVar(&backtrack, "backtrack", false, "search for the solution if the logical techniques get stuck")
+	flag.StringVar(&engine, "engine", "logic", "solving `engine`: \"logic\" for the logical techniques, \"dlx\" for dancing links")
 	flag.StringVar(&fname, "f", "", "instead looking for the puzzle string in the arguments, read puzzles from `file` (\"-\" for stdin), one per line")
 	flag.Parse()
 
@@ -44,6 +55,22 @@ func main() {
 	if step {
 		game.SetMode(jass.StepMode)
 	}
+	game.SetFishSize(fishSize)
+	game.SetMutantFish(mutant)
+	game.SetChainLength(chainLength)
+	game.SetALSNodes(alsNodes)
+	game.SetForcingDepth(forcingDepth)
+	game.SetUniqueness(unique)
+	game.SetExocet(exocet)
+	game.SetBacktracking(backtrack)
+	switch engine {
+	case "logic":
+		game.SetEngine(jass.LogicEngine)
+	case "dlx":
+		game.SetEngine(jass.DLXEngine)
+	default:
+		log.Fatalf("Unknown engine %q", engine)
+	}
 
 	if fname != "" {
 		var file *os.File